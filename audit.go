@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// exitFindings if any finding reaches threshold, errors exit with exitTrouble
+const exitFindings = 1
+
+type finding struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	Message  string `json:"message"`
+}
+
+func newFinding(f migration.Finding) finding {
+	return finding{
+		Severity: f.Severity.String(),
+		Check:    f.Check,
+		UUID:     f.Account.UUID().String(),
+		Name:     f.Account.Name,
+		Message:  f.Message,
+	}
+}
+
+// auditCmd reports weak practices, exit status is 1 on findings at or above
+// threshold and 2 on error
+func auditCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		threshold = fs.String("threshold", "medium", "lowest severity to fail on (low, medium, high)")
+		output    = fs.String("o", "text", outputUsage)
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	min, err := migration.ParseSeverity(*threshold)
+	if err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	findings := p.Audit()
+	if *output == "text" {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	} else {
+		r := make([]finding, len(findings))
+		for i, f := range findings {
+			r[i] = newFinding(f)
+		}
+		if err := writeRecords(os.Stdout, *output, r); err != nil {
+			return err
+		}
+	}
+	for _, f := range findings {
+		if f.Severity >= min {
+			return exitCode(exitFindings)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+const outputUsage = "output format (text, json, jsonl, csv, table)"
+
+// input flags shared by all commands
+type input struct {
+	link, linkFile, workdir, in, from, config string
+	tag, group                                string
+}
+
+// inputFlags selects input flags used by command, -workdir and -config
+// are registered for every command
+type inputFlags uint8
+
+const (
+	sourceFlags inputFlags = 1 << iota // -link, -link-file, -in
+	formatFlag                         // -from
+	filterFlags                        // -tag, -group
+
+	// loadFlags of commands reading accounts by input.load
+	loadFlags = sourceFlags | formatFlag | filterFlags
+)
+
+// flags registers selected input flags, current values are defaults
+func (in *input) flags(fs *flag.FlagSet, use inputFlags) {
+	fs.StringVar(&in.workdir, "workdir", in.workdir, "working directory")
+	fs.StringVar(&in.config, "config", in.config, "config file (default: $XDG_CONFIG_HOME/otpauth/config.toml)")
+	if use&sourceFlags != 0 {
+		fs.StringVar(&in.link, "link", in.link, "migration link, - to read from stdin")
+		fs.StringVar(&in.linkFile, "link-file", in.linkFile, "read migration links from file, one per line")
+		fs.StringVar(&in.in, "in", in.in, "input file instead of link or cache")
+	}
+	if use&formatFlag != 0 {
+		fs.StringVar(&in.from, "from", in.from, "input format (default: detect by extension or content)")
+	}
+	if use&filterFlags != 0 {
+		fs.StringVar(&in.tag, "tag", in.tag, "only vault accounts with tag")
+		fs.StringVar(&in.group, "group", in.group, "only vault accounts in group")
+	}
+}
+
+func (in *input) mkdir() error {
+	if in.workdir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(in.workdir, 0700); err != nil {
+		return fmt.Errorf("error creating working directory: %w", err)
+	}
+	return nil
+}
+
+func (in *input) cacheFile() string {
+	return filepath.Join(in.workdir, cacheFilename)
+}
+
+// load payload from link, input file, vault or cache
+func (in *input) load() (*migration.Payload, error) {
+	if err := in.mkdir(); err != nil {
+		return nil, err
+	}
+	v, err := in.loadVault()
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		return v.Payload(), nil
+	}
+	if in.tag != "" || in.group != "" {
+		return nil, errors.New("-tag and -group select vault accounts only")
+	}
+	links, err := readLinks(in.link, in.linkFile)
+	if err != nil {
+		return nil, fmt.Errorf("read links: %w", err)
+	}
+	p, err := loadPayload(in.cacheFile(), links, in.in, in.from)
+	if err != nil {
+		return nil, fmt.Errorf("decode data: %w", err)
+	}
+	return p, nil
+}
+
+// exitCode error terminates with given status
+type exitCode int
+
+func (e exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// runFunc runs command with its flag set and remaining arguments
+type runFunc func(fs *flag.FlagSet, in *input, args []string) error
+
+// exitUsage of any command on bad flags or arguments
+const exitUsage = 3
+
+// parseArgs parses command line, errors are already reported by fs
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, flag.ErrHelp):
+		return exitCode(0)
+	default:
+		return exitCode(exitUsage)
+	}
+}
+
+// exitTrouble of commands reporting their result by status 1, as diff(1)
+const exitTrouble = 2
+
+// troubled maps errors of command to status, so they are not mistaken
+// for its result, exit codes pass through
+func troubled(status int, run runFunc) runFunc {
+	return func(fs *flag.FlagSet, in *input, args []string) error {
+		var code exitCode
+		err := run(fs, in, args)
+		if err == nil || errors.As(err, &code) {
+			return err
+		}
+		log.Print(err)
+		return exitCode(status)
+	}
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	input   inputFlags
+	run     runFunc
+}
+
+var commands = []command{
+	{name: "decode", summary: "print accounts as otpauth links or in other format (default)", input: loadFlags, run: decodeCmd},
+	{name: "qr", summary: "generate QR-codes (otpauth://)", input: loadFlags, run: qrCmd},
+	{name: "rev", summary: "reverse QR-code (otpauth-migration://)", input: loadFlags, run: revCmd},
+	{name: "eval", summary: "evaluate otps", input: loadFlags, run: evalCmd},
+	{name: "watch", summary: "live view of otps in terminal", input: loadFlags, run: watchCmd},
+	{name: "code", args: "<query>", summary: "print single code of matching account", input: loadFlags, run: troubled(exitCodeError, runCode)},
+	{name: "exec", args: "-- command [args...]", summary: "run command with code injected", input: loadFlags, run: runExec},
+	{name: "paper", summary: "printable PDF backup", input: loadFlags, run: paperCmd},
+	{name: "info", summary: "display batch info", input: loadFlags, run: infoCmd},
+	{name: "dump", summary: "dump as prototext", input: loadFlags, run: dumpCmd},
+	{name: "serve", summary: "serve http", input: loadFlags, run: serveCmd},
+	{name: "import", args: "file|-...", summary: "import accounts of any format into vault or cache", input: formatFlag, run: importCmd},
+	{name: "recover", args: "file|-...", summary: "recover accounts from shamir shares into vault or cache", run: recoverCmd},
+	{name: "restore", args: "[file...]", summary: "restore accounts from typed paper key into vault or cache", run: troubled(exitTrouble, restoreCmd)},
+	{name: "merge", args: "file|-...", summary: "merge accounts without duplicates", input: formatFlag, run: mergeCmd},
+	{name: "diff", args: "file|- file", summary: "compare accounts of two exports", input: formatFlag, run: troubled(exitTrouble, diffCmd)},
+	{name: "audit", summary: "report weak secrets, algorithms and labels", input: loadFlags, run: troubled(exitTrouble, auditCmd)},
+	{name: "export", summary: "export accounts to other format or pass store", input: loadFlags, run: exportCmd},
+	{name: "vault", args: "add|remove|rename|edit|list [flags] [query|file|-...]", summary: "manage accounts stored in working directory", input: loadFlags, run: vaultCmd},
+}
+
+// config command runs the others, so it is added on init to avoid
+// initialization cycle
+func init() {
+	commands = append(commands, command{name: "config", args: "show [command [flags]]", summary: "print effective flags of commands", run: configCmd})
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand parses common and command flags and runs command
+func runCommand(c command, in *input, args []string) error {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	in.flags(fs, c.input)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: otpauth %s\n\n%s\n\n", strings.TrimSpace(c.name+" [flags] "+c.args), c.summary)
+		fs.PrintDefaults()
+	}
+	return c.run(fs, in, args)
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: otpauth <command> [flags] [args]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nRun \"otpauth <command> -h\" for command flags.\n")
+	fmt.Fprintf(w, "Formats: %s\n", strings.Join(migration.Formats(), ", "))
+	fmt.Fprintf(w, "\nFlags without command, mode flags (-http, -qr, -rev, -eval, -info, -dump, -watch, -pass) are deprecated:\n")
+	flag.PrintDefaults()
+}
+
+func decodeCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		to     = fs.String("to", "otpauth", "output format")
+		out    = fs.String("out", "", "output file (default: stdout)")
+		format = fs.String("format", "", "render each account with text/template (e.g. '{{.Issuer}}\\t{{.Name}}\\t{{.Code}}')")
+		output = fs.String("o", "text", outputUsage+", overrides -to")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	return decode(p, *to, *out, *format, *output)
+}
+
+func qrCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		term   = fs.String("term", "", "render in terminal (half, ansi)")
+		invert = fs.Bool("invert", false, "invert terminal QR-codes for light terminals")
+		name   = fs.String("name-template", "", "file name template without extension (default '{{.FileName}}')")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	return writeQR(p, in.workdir, *term, *invert, *name)
+}
+
+func revCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		term   = fs.String("term", "", "render in terminal (half, ansi)")
+		invert = fs.Bool("invert", false, "invert terminal QR-codes for light terminals")
+		batch  = fs.Int("batch", 0, "accounts per migration batch (0 for single batch)")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	return writeRev(p, in.workdir, *term, *invert, *batch)
+}
+
+func evalCmd(fs *flag.FlagSet, in *input, args []string) error {
+	output := fs.String("o", "text", outputUsage)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	if err := printEval(p, *output); err != nil {
+		return err
+	}
+	return in.saveCounter(p.OtpParameters...)
+}
+
+func watchCmd(fs *flag.FlagSet, in *input, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	return watch(p)
+}
+
+func paperCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		out   = fs.String("out", "otpauth-backup.pdf", "output file")
+		batch = fs.Int("batch", 10, "accounts per migration batch (0 for single batch)")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	data, err := p.Paper(time.Now(), *batch)
+	if err != nil {
+		return err
+	}
+	sum, err := p.Checksum()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "checksum %s, verify with \"otpauth info\"\n", sum)
+	return os.WriteFile(*out, data, 0600)
+}
+
+func infoCmd(fs *flag.FlagSet, in *input, args []string) error {
+	output := fs.String("o", "text", outputUsage)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	return printInfo(p, *output)
+}
+
+func dumpCmd(fs *flag.FlagSet, in *input, args []string) error {
+	out := fs.String("out", "", "output file (default: stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	return encode(p, "prototext", *out)
+}
+
+func serveCmd(fs *flag.FlagSet, in *input, args []string) error {
+	addr := fs.String("addr", "localhost:6060", "listen address")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	v, err := in.accounts()
+	if err != nil {
+		return err
+	}
+	return serve(*addr, v)
+}
+
+func importCmd(fs *flag.FlagSet, in *input, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	if err := in.mkdir(); err != nil {
+		return err
+	}
+	var ops []*migration.Payload_OtpParameters
+	for _, fname := range fs.Args() {
+		p, err := readPayload(fname, in.from)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, p.OtpParameters...)
+	}
+	return in.store("imported", migration.NewPayload(ops))
+}
+
+func recoverCmd(fs *flag.FlagSet, in *input, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	if err := in.mkdir(); err != nil {
+		return err
+	}
+	var links []byte
+	for _, arg := range fs.Args() {
+		data, err := readArg(arg)
+		if err != nil {
+			return err
+		}
+		links = append(append(links, data...), '\n')
+	}
+	p, err := migration.UnmarshalShares(links)
+	if err != nil {
+		return err
+	}
+	return in.store("recovered", p)
+}
+
+// exitDamaged if paper key has damaged lines, errors exit with exitTrouble
+const exitDamaged = 1
+
+func restoreCmd(fs *flag.FlagSet, in *input, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := in.mkdir(); err != nil {
+		return err
+	}
+	var text []byte
+	if fs.NArg() == 0 {
+		if isTerminal(os.Stdin) {
+			fmt.Fprintln(os.Stderr, "Type paper key lines, end with Ctrl-D:")
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = data
+	}
+	for _, fname := range fs.Args() {
+		data, err := os.ReadFile(fname)
+		if err != nil {
+			return err
+		}
+		text = append(text, data...)
+		text = append(text, '\n')
+	}
+	p, err := migration.UnmarshalPaperKey(text)
+	if err != nil {
+		// one line per damaged line of paper key
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode(exitDamaged)
+	}
+	return in.store("restored", p)
+}
+
+func mergeCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		resolve = fs.String("resolve", "first", "resolve conflicts by keeping first or last account, or fail")
+		to      = fs.String("to", "link", "output format")
+		out     = fs.String("out", "", "output file (default: stdout)")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	res, err := migration.ParseResolution(*resolve)
+	if err != nil {
+		return err
+	}
+	var ps []*migration.Payload
+	for _, arg := range fs.Args() {
+		p, err := readPayload(arg, in.from)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, p)
+	}
+	p, conflicts, err := migration.Merge(res, ps...)
+	for _, c := range conflicts {
+		fmt.Fprintln(os.Stderr, "conflict:", c)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "merged %d accounts, %d conflicts resolved by keeping %s\n",
+		len(p.OtpParameters), len(conflicts), res)
+	return encode(p, *to, *out)
+}
+
+func exportCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		to      = fs.String("to", "link", "output format")
+		out     = fs.String("out", "", "output file (default: stdout)")
+		pass    = fs.String("pass", "", "export pass-otp entries into directory instead")
+		ageFile = fs.String("age", "", "age recipients file to encrypt -pass entries for passage instead of gpg")
+		html    = fs.String("html", "", "export self-contained offline HTML file instead")
+		encrypt = fs.Bool("encrypt", false, "encrypt -html with passphrase read from terminal or stdin")
+		shamir  = fs.String("shamir", "", "split encrypted payload into k-of-n share files and QR-codes instead (e.g. 2-of-3)")
+		ga      googleAuthFlags
+	)
+	ga.flags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *encrypt && *html == "" {
+		fmt.Fprintln(os.Stderr, "otpauth: -encrypt requires -html")
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	if *html != "" {
+		v, err := in.accounts()
+		if err != nil {
+			return err
+		}
+		return writeOffline(v, *html, *encrypt)
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	if *shamir != "" {
+		return writeShares(p, in.workdir, *shamir)
+	}
+	if *pass != "" {
+		return exportPass(*pass, *ageFile, p)
+	}
+	if *to == "google-authenticator" {
+		data, err := googleAuthenticator(p, &ga)
+		if err != nil {
+			return err
+		}
+		return writeOut(data, *out)
+	}
+	return encode(p, *to, *out)
+}
+
+// configCmd shows effective flags of command, or of all commands, by running
+// them until their flags are parsed; -workdir and -config are passed on
+func configCmd(fs *flag.FlagSet, in *input, args []string) error {
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.Arg(0) != "show" {
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	// flags may follow show as well
+	set, err := parseExplicit(fs, fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	var common []string
+	if set["workdir"] {
+		common = append(common, "-workdir", in.workdir)
+	}
+	if set["config"] {
+		common = append(common, "-config", in.config)
+	}
+	targets := commands
+	if fs.NArg() > 0 {
+		c, ok := lookupCommand(fs.Arg(0))
+		if !ok {
+			fmt.Fprintln(os.Stderr, "otpauth: unknown command", fs.Arg(0))
+			fs.Usage()
+			return exitCode(exitUsage)
+		}
+		targets, common = []command{c}, append(common, fs.Args()[1:]...)
+	}
+	showEffective = true
+	defer func() { showEffective = false }()
+	for _, c := range targets {
+		if c.name == "config" {
+			continue
+		}
+		fmt.Printf("# %s\n", c.name)
+		// commands stop with status 0 once their flags are shown
+		var code exitCode
+		if err := runCommand(c, &input{}, common); !errors.As(err, &code) || code != 0 {
+			return err
+		}
+	}
+	return nil
+}
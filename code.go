@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// exit status of code command, other errors exit with exitCodeError
+const (
+	exitNoMatch   = 1
+	exitAmbiguous = 2
+	exitCodeError = 4
+)
+
+// waitFresh sleeps into next validity frame if less than min seconds are left
+func waitFresh(op *migration.Payload_OtpParameters, min int) {
+	if op.Period() == 0 || op.Remaining() >= min {
+		return
+	}
+	left := float64(op.Period()) - op.Seconds()
+	time.Sleep(time.Duration(left*float64(time.Second)) + 10*time.Millisecond)
+}
+
+// runCode prints exactly one code of account matching query
+func runCode(fs *flag.FlagSet, in *input, args []string) error {
+	wait := fs.Int("wait", 0, "wait for fresh code if less than `seconds` remain")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	p, err := in.load()
+	if err != nil {
+		return err
+	}
+	op, err := findAccount(p, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	waitFresh(op, *wait)
+	fmt.Println(op.EvaluateString())
+	return in.saveCounter(op)
+}
+
+// findAccount reports match failures and maps them to exit status
+func findAccount(p *migration.Payload, query string) (*migration.Payload_OtpParameters, error) {
+	op, err := p.Find(query)
+	if err != nil {
+		return nil, matchError(err)
+	}
+	return op, nil
+}
+
+// matchError reports match failure and maps it to exit status
+func matchError(err error) error {
+	fmt.Fprintln(os.Stderr, "otpauth:", err)
+	if errors.Is(err, migration.ErrAmbiguous) {
+		return exitCode(exitAmbiguous)
+	}
+	return exitCode(exitNoMatch)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	configFilename = "config.toml"
+	// workdir config is named after tool, as working directory defaults to
+	// current one, which may hold config.toml of something else
+	localConfigFilename = "otpauth.toml"
+	envPrefix           = "OTPAUTH_"
+)
+
+// parseTOML reads a subset of TOML: [section] tables and key = value
+// pairs of strings, integers and booleans, keys are flattened to section.key
+func parseTOML(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	var section string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated section", n)
+			}
+			section = strings.TrimSpace(line[1:end])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing =", n)
+		}
+		key, value = strings.Trim(strings.TrimSpace(key), `"`), strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			q, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			value, _ = strconv.Unquote(q)
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", n)
+			}
+			value = value[1 : end+1]
+		default:
+			value, _, _ = strings.Cut(value, "#")
+			value = strings.TrimSpace(value)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = value
+	}
+	return values, s.Err()
+}
+
+// source of configuration values
+type source struct {
+	name   string
+	values map[string]string
+}
+
+// userConfig returns default path of user config file
+func userConfig() string {
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "otpauth", configFilename)
+}
+
+func readConfig(path string) (source, error) {
+	src := source{name: path, values: map[string]string{}}
+	if path == "" {
+		return src, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return src, nil
+	}
+	if err != nil {
+		return src, err
+	}
+	src.values, err = parseTOML(data)
+	if err != nil {
+		return src, fmt.Errorf("%s: %w", path, err)
+	}
+	return src, nil
+}
+
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// envSource collects OTPAUTH_* variables
+func envSource() source {
+	src := source{name: "environment", values: map[string]string{}}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, envPrefix) && k != envPrefix+"CONFIG" {
+			src.values[k] = v
+		}
+	}
+	return src
+}
+
+// secretKeys carry account secrets, config show masks their values
+var secretKeys = map[string]bool{"link": true, "secret": true}
+
+// isSecret reports whether key or environment variable names a secret flag
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	if i := strings.LastIndexAny(key, "._"); i >= 0 {
+		key = key[i+1:]
+	}
+	return secretKeys[key]
+}
+
+// configSources in order of precedence: environment, workdir config, user config
+func configSources(configPath, workdir string) ([]source, error) {
+	env := envSource()
+	user, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if workdir == "" {
+		if workdir = env.values[envName("workdir")]; workdir == "" {
+			workdir = user.values["workdir"]
+		}
+	}
+	local, err := readConfig(filepath.Join(workdir, localConfigFilename))
+	if err != nil {
+		return nil, err
+	}
+	return []source{env, local, user}, nil
+}
+
+// lookup flag value for section, most specific first within each source,
+// origin names key and source of value
+func lookup(sources []source, section, name string) (value, origin string, ok bool) {
+	for _, src := range sources {
+		keys := []string{name}
+		if section != "" {
+			keys = []string{section + "." + name, name}
+		}
+		for _, key := range keys {
+			if src.name == "environment" {
+				key = envName(key)
+			}
+			if v, ok := src.values[key]; ok {
+				if src.name == "environment" {
+					return v, key, true
+				}
+				return v, key + " in " + src.name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// parseFlags parses command line and fills flags not given explicitly
+// from environment and config files. Precedence is: command line,
+// OTPAUTH_<COMMAND>_<FLAG>, OTPAUTH_<FLAG>, workdir config, user config.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	_, err := parseExplicit(fs, args)
+	return err
+}
+
+// parseExplicit is parseFlags returning flags given on command line, as
+// fs.Visit reports flags filled from environment and config files as well
+func parseExplicit(fs *flag.FlagSet, args []string) (map[string]bool, error) {
+	if err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	section := fs.Name()
+	if fs == flag.CommandLine {
+		section = ""
+	}
+	explicit := make(map[string]bool)
+	origins := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+		origins[f.Name] = "command line"
+	})
+	configPath, workdir := userConfig(), ""
+	if f := fs.Lookup("config"); f != nil && f.Value.String() != "" {
+		configPath = f.Value.String()
+	}
+	if explicit["workdir"] {
+		workdir = fs.Lookup("workdir").Value.String()
+	}
+	sources, err := configSources(configPath, workdir)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if explicit[f.Name] || f.Name == "config" {
+			return
+		}
+		if v, origin, ok := lookup(sources, section, f.Name); ok {
+			if err := fs.Set(f.Name, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", origin, err))
+			}
+			origins[f.Name] = origin
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if showEffective {
+		showFlags(fs, configPath, origins)
+		return nil, exitCode(0)
+	}
+	return explicit, nil
+}
+
+// showEffective makes parseFlags print effective flags instead of running
+// command, see config show
+var showEffective bool
+
+// showFlags prints effective value of every flag and where it comes from,
+// secrets are masked
+func showFlags(fs *flag.FlagSet, configPath string, origins map[string]string) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fs.VisitAll(func(f *flag.Flag) {
+		value, origin := f.Value.String(), origins[f.Name]
+		if origin == "" {
+			origin = "default"
+		}
+		if f.Name == "config" && value == "" {
+			value = configPath
+		}
+		if isSecret(f.Name) && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(tw, "-%s\t%q\t%s\n", f.Name, value, origin)
+	})
+	tw.Flush()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want map[string]string
+		err  bool
+	}{
+		{
+			name: "empty",
+			want: map[string]string{},
+		},
+		{
+			name: "top level",
+			data: "# comment\nworkdir = \"/tmp/otp\"\nbatch = 5 # trailing\n",
+			want: map[string]string{"workdir": "/tmp/otp", "batch": "5"},
+		},
+		{
+			name: "sections",
+			data: "format = \"x\"\n[eval]\no = 'json'\n[ qr ]\n\"invert\" = true\n",
+			want: map[string]string{"format": "x", "eval.o": "json", "qr.invert": "true"},
+		},
+		{
+			name: "quoted",
+			data: "a = \"tab\\there # not comment\"\nb = 'C:\\path'\n",
+			want: map[string]string{"a": "tab\there # not comment", "b": `C:\path`},
+		},
+		{
+			name: "unterminated section",
+			data: "[eval\n",
+			err:  true,
+		},
+		{
+			name: "missing equal sign",
+			data: "workdir\n",
+			err:  true,
+		},
+		{
+			name: "unterminated string",
+			data: "a = 'b\n",
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTOML([]byte(tc.data))
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v; want error %v", err, tc.err)
+			}
+			if !tc.err && !maps.Equal(got, tc.want) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	testCases := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		local string // workdir config
+		user  string // user config
+		want  string
+	}{
+		{
+			name: "default",
+			want: "text",
+		},
+		{
+			name: "user config",
+			user: "o = \"csv\"\n",
+			want: "csv",
+		},
+		{
+			name: "user config section",
+			user: "o = \"csv\"\n[eval]\no = \"table\"\n",
+			want: "table",
+		},
+		{
+			name:  "workdir config over user config",
+			local: "o = \"jsonl\"\n",
+			user:  "[eval]\no = \"table\"\n",
+			want:  "jsonl",
+		},
+		{
+			name:  "environment over config",
+			env:   map[string]string{"OTPAUTH_O": "json"},
+			local: "[eval]\no = \"jsonl\"\n",
+			want:  "json",
+		},
+		{
+			name: "command environment over environment",
+			env:  map[string]string{"OTPAUTH_O": "json", "OTPAUTH_EVAL_O": "csv"},
+			want: "csv",
+		},
+		{
+			name:  "flag over all",
+			args:  []string{"-o", "table"},
+			env:   map[string]string{"OTPAUTH_EVAL_O": "csv"},
+			local: "o = \"jsonl\"\n",
+			user:  "o = \"json\"\n",
+			want:  "table",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			workdir := filepath.Join(dir, "work")
+			if err := os.Mkdir(workdir, 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(workdir, localConfigFilename), []byte(tc.local), 0600); err != nil {
+				t.Fatal(err)
+			}
+			userFile := filepath.Join(dir, "user.toml")
+			if err := os.WriteFile(userFile, []byte(tc.user), 0600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv(envPrefix+"CONFIG", userFile)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+			o := fs.String("o", "text", "output")
+			fs.String("workdir", "", "working directory")
+			fs.String("config", "", "config file")
+			args := append([]string{"-workdir", workdir}, tc.args...)
+			if err := parseFlags(fs, args); err != nil {
+				t.Fatal(err)
+			}
+			if *o != tc.want {
+				t.Errorf("got %q; want %q", *o, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	testCases := map[string]bool{
+		"link":              true,
+		"eval.link":         true,
+		"vault.secret":      true,
+		"OTPAUTH_LINK":      true,
+		"OTPAUTH_EVAL_LINK": true,
+		"link-file":         false,
+		"OTPAUTH_LINK_FILE": false,
+		"workdir":           false,
+	}
+	for key, want := range testCases {
+		if got := isSecret(key); got != want {
+			t.Errorf("%s: got %v; want %v", key, got, want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	sources := []source{
+		{name: "environment", values: map[string]string{"OTPAUTH_O": "json"}},
+		{name: "otpauth.toml", values: map[string]string{"eval.o": "csv", "workdir": "/tmp"}},
+	}
+	testCases := []struct {
+		section, name string
+		value, origin string
+		ok            bool
+	}{
+		{section: "eval", name: "o", value: "json", origin: "OTPAUTH_O", ok: true},
+		{section: "eval", name: "workdir", value: "/tmp", origin: "workdir in otpauth.toml", ok: true},
+		{section: "qr", name: "term"},
+	}
+	for _, tc := range testCases {
+		value, origin, ok := lookup(sources, tc.section, tc.name)
+		if value != tc.value || origin != tc.origin || ok != tc.ok {
+			t.Errorf("%s.%s: got %q %q %v; want %q %q %v", tc.section, tc.name, value, origin, ok, tc.value, tc.origin, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// exitDiffer like diff(1) if payloads differ, errors exit with exitTrouble
+const exitDiffer = 1
+
+// difference is a single row of diff output, one per changed field
+type difference struct {
+	Change string `json:"change"`
+	UUID   string `json:"uuid"`
+	Name   string `json:"name"`
+	Field  string `json:"field"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// fieldValue of otp parameters, secret is hidden unless asked for
+func fieldValue(op *migration.Payload_OtpParameters, field string, secrets bool) string {
+	switch field {
+	case "secret":
+		if !secrets {
+			return "(hidden)"
+		}
+		return op.SecretString()
+	case "name":
+		return op.Name
+	case "issuer":
+		return op.Issuer
+	case "algorithm":
+		return op.Algorithm.Name()
+	case "digits":
+		return fmt.Sprint(op.Digits.Count())
+	case "type":
+		return op.Type.Name()
+	case "counter":
+		return fmt.Sprint(op.Counter)
+	}
+	return ""
+}
+
+func differences(changes []migration.Change, secrets bool) []difference {
+	var r []difference
+	for _, c := range changes {
+		switch c.Kind {
+		case migration.Added:
+			r = append(r, difference{Change: c.Kind.String(), UUID: c.New.UUID().String(), Name: c.New.Name})
+		case migration.Removed:
+			r = append(r, difference{Change: c.Kind.String(), UUID: c.Old.UUID().String(), Name: c.Old.Name})
+		case migration.Changed:
+			for _, f := range c.Fields {
+				r = append(r, difference{
+					Change: c.Kind.String(),
+					UUID:   c.Old.UUID().String(),
+					Name:   c.Old.Name,
+					Field:  f,
+					Old:    fieldValue(c.Old, f, secrets),
+					New:    fieldValue(c.New, f, secrets),
+				})
+			}
+		}
+	}
+	return r
+}
+
+// printDiff in unified diff like notation
+func printDiff(changes []migration.Change, secrets bool) {
+	for _, c := range changes {
+		switch c.Kind {
+		case migration.Added:
+			fmt.Println("+", c.New.Name)
+		case migration.Removed:
+			fmt.Println("-", c.Old.Name)
+		case migration.Changed:
+			fmt.Println("~", c.Old.Name)
+			for _, f := range c.Fields {
+				fmt.Printf("    %s: %q -> %q\n", f, fieldValue(c.Old, f, secrets), fieldValue(c.New, f, secrets))
+			}
+		}
+	}
+}
+
+// diffCmd compares two payloads, exit status is 1 if they differ and 2 on error
+func diffCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		secrets = fs.Bool("secrets", false, "show changed secrets")
+		output  = fs.String("o", "text", outputUsage)
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	a, err := readPayload(fs.Arg(0), in.from)
+	if err != nil {
+		return err
+	}
+	b, err := readPayload(fs.Arg(1), in.from)
+	if err != nil {
+		return err
+	}
+	changes := migration.Diff(a, b)
+	if *output == "text" {
+		printDiff(changes, *secrets)
+	} else if err := writeRecords(os.Stdout, *output, differences(changes, *secrets)); err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		return exitCode(exitDiffer)
+	}
+	return nil
+}
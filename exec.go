@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// exit status of exec command failures, as env(1) and timeout(1)
+const (
+	exitExecFailed = 125 // otpauth itself failed
+	exitCannotRun  = 126 // command found, but cannot be invoked
+	exitNotFound   = 127 // command not found
+)
+
+// exitStatus of finished command, 128+n if killed by signal n
+func exitStatus(err error) int {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return exitExecFailed
+	}
+	if ws, ok := exitErr.Sys().(interface {
+		Signaled() bool
+		Signal() syscall.Signal
+	}); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// childEnv is environment without OTPAUTH_* variables, which may carry secrets
+func childEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envPrefix) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// startError maps error starting command to exit status, as shells do
+func startError(err error) exitCode {
+	fmt.Fprintln(os.Stderr, "otpauth:", err)
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return exitNotFound
+	}
+	return exitCannotRun
+}
+
+// execFailed maps own failures of exec command to exitExecFailed, so they
+// are not mistaken for status of command, help exits with 0
+func execFailed(err error) error {
+	var code exitCode
+	if !errors.As(err, &code) {
+		log.Print(err)
+	} else if code == 0 {
+		return code
+	}
+	return exitCode(exitExecFailed)
+}
+
+// runExec runs command with code of matching account injected
+func runExec(fs *flag.FlagSet, in *input, args []string) error {
+	cmd, err := execCommand(fs, in, args)
+	if err != nil {
+		return execFailed(err)
+	}
+	// terminal sends SIGINT and SIGQUIT to whole process group, child gets
+	// them on its own, so they are not forwarded, even if sent to otpauth
+	// alone; SIGTERM and SIGHUP are. Handled signals are reset on exec,
+	// unlike ignored ones, which child would inherit.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	if err := cmd.Start(); err != nil {
+		return startError(err)
+	}
+	go func() {
+		for s := range sig {
+			if s == os.Interrupt || s == syscall.SIGQUIT {
+				continue
+			}
+			cmd.Process.Signal(s)
+		}
+	}()
+	if err := cmd.Wait(); err != nil {
+		return exitCode(exitStatus(err))
+	}
+	return nil
+}
+
+// execCommand prepares command with code of matching account injected
+func execCommand(fs *flag.FlagSet, in *input, args []string) (*exec.Cmd, error) {
+	account := fs.String("account", "", "account `query` as for code command (required)")
+	env := fs.String("env", "OTP", "environment `variable` receiving code")
+	stdin := fs.Bool("stdin", false, "write code to command stdin instead of environment")
+	wait := fs.Int("wait", 5, "wait for fresh code if less than `seconds` remain")
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	if *account == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return nil, exitCode(exitUsage)
+	}
+	p, err := in.load()
+	if err != nil {
+		return nil, err
+	}
+	op, err := findAccount(p, *account)
+	if err != nil {
+		return nil, err
+	}
+	waitFresh(op, *wait)
+	code := op.EvaluateString()
+	if err := in.saveCounter(op); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(fs.Arg(0), fs.Args()[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = childEnv()
+	if *stdin {
+		cmd.Stdin = strings.NewReader(code + "\n")
+	} else {
+		cmd.Env = append(cmd.Env, *env+"="+code)
+	}
+	return cmd, nil
+}
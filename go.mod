@@ -3,9 +3,16 @@ module github.com/dim13/otpauth
 go 1.24
 
 require (
+	filippo.io/age v1.2.1
 	github.com/google/uuid v1.6.0
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/term v0.21.0
 	google.golang.org/protobuf v1.36.11
 )
 
+require (
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
 tool google.golang.org/protobuf/cmd/protoc-gen-go
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// googleAuthFlags are options of exported ~/.google_authenticator files
+type googleAuthFlags struct {
+	scratchCodes  int
+	windowSize    int
+	rateLimit     string
+	disallowReuse bool
+}
+
+func (g *googleAuthFlags) flags(fs *flag.FlagSet) {
+	fs.IntVar(&g.scratchCodes, "scratch-codes", 5, "fresh emergency scratch codes of google-authenticator export")
+	fs.IntVar(&g.windowSize, "window-size", 0, "WINDOW_SIZE of google-authenticator export, 0 to omit")
+	fs.StringVar(&g.rateLimit, "rate-limit", "", "RATE_LIMIT `attempts/seconds` of google-authenticator export (e.g. 3/30)")
+	fs.BoolVar(&g.disallowReuse, "disallow-reuse", false, "DISALLOW_REUSE in google-authenticator export")
+}
+
+func (g *googleAuthFlags) options() (*migration.GoogleAuthenticatorOptions, error) {
+	opt := &migration.GoogleAuthenticatorOptions{
+		WindowSize:    g.windowSize,
+		DisallowReuse: g.disallowReuse,
+	}
+	if g.rateLimit != "" {
+		if _, err := fmt.Sscanf(g.rateLimit, "%d/%d", &opt.RateLimit, &opt.RateInterval); err != nil {
+			return nil, fmt.Errorf("rate limit %s: want attempts/seconds: %w", g.rateLimit, err)
+		}
+	}
+	if g.scratchCodes > 0 {
+		var err error
+		if opt.ScratchCodes, err = migration.ScratchCodes(g.scratchCodes); err != nil {
+			return nil, err
+		}
+	}
+	return opt, nil
+}
+
+// googleAuthenticator generates ~/.google_authenticator file of single account
+func googleAuthenticator(p *migration.Payload, g *googleAuthFlags) ([]byte, error) {
+	if len(p.OtpParameters) != 1 {
+		return nil, fmt.Errorf("%d accounts: want exactly one", len(p.OtpParameters))
+	}
+	opt, err := g.options()
+	if err != nil {
+		return nil, err
+	}
+	return p.OtpParameters[0].GoogleAuthenticator(opt)
+}
@@ -55,20 +55,21 @@ func eventStream(event string, p *migration.Payload) http.HandlerFunc {
 	}
 }
 
-func indexHandler(t *template.Template, p *migration.Payload) http.HandlerFunc {
+func indexHandler(t *template.Template, v *migration.Vault) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := t.Execute(w, p); err != nil {
+		if err := t.Execute(w, v.Groups()); err != nil {
 			log.Println("execute template:", err)
 		}
 	}
 }
 
-func serve(addr string, p *migration.Payload) error {
+func serve(addr string, v *migration.Vault) error {
 	t, err := template.ParseFS(static, "static/index.html")
 	if err != nil {
 		return err
 	}
-	http.Handle("/", indexHandler(t, p))
+	p := v.Payload()
+	http.Handle("/", indexHandler(t, v))
 	for _, op := range p.OtpParameters {
 		http.Handle("/"+op.UUID().String()+".png", op)
 	}
@@ -4,11 +4,17 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/dim13/otpauth/migration"
 )
@@ -16,14 +22,50 @@ import (
 const (
 	cacheFilename = "migration.bin"
 	revFile       = "otpauth-migration.png"
+	manifestFile  = "manifest.json"
 )
 
-func migrationData(fname, link string) ([]byte, error) {
-	if link == "" {
+// readLinks returns migration links from argument, stdin or file
+func readLinks(link, linkFile string) ([]byte, error) {
+	switch {
+	case link == "-":
+		return io.ReadAll(os.Stdin)
+	case linkFile != "":
+		return os.ReadFile(linkFile)
+	default:
+		return []byte(link), nil
+	}
+}
+
+func migrationData(fname string, links []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(links)) == 0 {
 		// read from cache
 		return os.ReadFile(fname)
 	}
-	data, err := migration.Data(link)
+	var p *migration.Payload
+	for i, link := range migration.Links(links) {
+		data, norm, err := migration.DataNormalized(link)
+		if err != nil {
+			return nil, err
+		}
+		if norm != 0 {
+			log.Printf("link %d repaired: %v", i+1, norm)
+		}
+		q, err := migration.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			p = q
+			continue
+		}
+		p.OtpParameters = append(p.OtpParameters, q.OtpParameters...)
+		p.BatchSize, p.BatchIndex = 1, 0
+	}
+	if p == nil {
+		return nil, errors.New("no migration link")
+	}
+	data, err := migration.Marshal(p)
 	if err != nil {
 		return nil, err
 	}
@@ -31,68 +73,355 @@ func migrationData(fname, link string) ([]byte, error) {
 	return data, os.WriteFile(fname, data, 0600)
 }
 
-func main() {
+func loadPayload(cacheFile string, links []byte, in, from string) (*migration.Payload, error) {
+	if in != "" {
+		data, err := os.ReadFile(in)
+		if err != nil {
+			return nil, err
+		}
+		return migration.Decode(from, in, data)
+	}
+	data, err := migrationData(cacheFile, links)
+	if errors.Is(err, fs.ErrNotExist) && len(links) == 0 {
+		return nil, fmt.Errorf("-link parameter or cache file missing: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return migration.Unmarshal(data)
+}
+
+// readArg reads file, or stdin if arg is "-"
+func readArg(arg string) ([]byte, error) {
+	if arg == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(arg)
+}
+
+// readPayload decodes file of any format, or stdin if arg is "-". Links are
+// not accepted as argument, as command line is visible to other users.
+func readPayload(arg, from string) (*migration.Payload, error) {
+	if strings.HasPrefix(arg, "otpauth://") || strings.HasPrefix(arg, "otpauth-migration://") {
+		return nil, errors.New("links on command line are visible to other users, pass them on stdin with -")
+	}
+	data, err := readArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	if arg == "-" {
+		return migration.Decode(from, "", data)
+	}
+	p, err := migration.Decode(from, arg, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	return p, nil
+}
+
+func encode(p *migration.Payload, name, out string) error {
+	f, err := migration.Lookup(name)
+	if err != nil {
+		return err
+	}
+	data, err := f.Encode(p)
+	if err != nil {
+		return err
+	}
+	return writeOut(data, out)
+}
+
+// writeOut writes data to file, or to stdout if out is empty
+func writeOut(data []byte, out string) error {
+	if out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0600)
+}
+
+func exportPass(dir, recipients string, p *migration.Payload) error {
+	if recipients == "" {
+		return migration.ExportPassGPG(dir, p)
+	}
+	f, err := os.Open(recipients)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rcpts, err := migration.ParseAgeRecipients(f)
+	if err != nil {
+		return err
+	}
+	return migration.ExportPass(dir, p, rcpts...)
+}
+
+// decode lists accounts as template, records or encoded format
+func decode(p *migration.Payload, to, out, format, output string) error {
+	switch {
+	case format != "":
+		return renderTemplate(os.Stdout, format, p)
+	case output != "text":
+		return writeRecords(os.Stdout, output, records(p, newAccount))
+	default:
+		return encode(p, to, out)
+	}
+}
+
+// manifestEntry maps QR-code file to its account
+type manifestEntry struct {
+	File   string `json:"file"`
+	UUID   string `json:"uuid"`
+	Name   string `json:"name"`
+	Issuer string `json:"issuer"`
+}
+
+// qrFileNames renders file name of every account, names colliding on
+// case-insensitive file systems are disambiguated by UUID prefix
+func qrFileNames(p *migration.Payload, nameTemplate string) ([]string, error) {
+	names := make([]string, len(p.OtpParameters))
+	for i, op := range p.OtpParameters {
+		names[i] = op.FileName()
+	}
+	if nameTemplate != "" {
+		var b strings.Builder
+		for i, op := range p.OtpParameters {
+			b.Reset()
+			if err := renderTemplate(&b, nameTemplate, migration.NewPayload([]*migration.Payload_OtpParameters{op})); err != nil {
+				return nil, err
+			}
+			names[i] = pathSeparators.Replace(strings.TrimSpace(b.String()))
+		}
+	}
+	count := make(map[string]int)
+	for _, name := range names {
+		count[strings.ToLower(name)]++
+	}
+	seen := make(map[string]int)
+	for i, op := range p.OtpParameters {
+		name := names[i]
+		if count[strings.ToLower(name)] > 1 {
+			name += "_" + op.UUID().String()[:8]
+		}
+		// same secret yields same UUID
+		if n := seen[strings.ToLower(name)]; n > 0 {
+			seen[strings.ToLower(name)]++
+			name += fmt.Sprintf("_%d", n)
+		}
+		seen[strings.ToLower(name)]++
+		names[i] = name + ".png"
+	}
+	return names, nil
+}
+
+var pathSeparators = strings.NewReplacer("/", "_", `\`, "_")
+
+func writeQR(p *migration.Payload, workdir, term string, invert bool, nameTemplate string) error {
+	if term != "" {
+		codes := make([]termQR, len(p.OtpParameters))
+		for i, op := range p.OtpParameters {
+			codes[i] = termQR{title: op.Name, url: op.URL()}
+		}
+		return showQR(term, invert, codes)
+	}
+	names, err := qrFileNames(p, nameTemplate)
+	if err != nil {
+		return err
+	}
+	manifest := make([]manifestEntry, len(names))
+	for i, op := range p.OtpParameters {
+		qrFile := filepath.Join(workdir, names[i])
+		if err := migration.PNG(qrFile, op.URL()); err != nil {
+			return fmt.Errorf("write file: %w", err)
+		}
+		manifest[i] = manifestEntry{File: names[i], UUID: op.UUID().String(), Name: op.Name, Issuer: op.Issuer}
+	}
+	f, err := os.OpenFile(filepath.Join(workdir, manifestFile), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := writeRecords(f, "json", manifest); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeRev(p *migration.Payload, workdir, term string, invert bool, batch int) error {
+	batches := p.Batches(batch)
+	codes := make([]termQR, len(batches))
+	for i, b := range batches {
+		data, err := migration.Marshal(b)
+		if err != nil {
+			return err
+		}
+		codes[i] = termQR{
+			title: fmt.Sprintf("batch %d of %d", i+1, len(batches)),
+			url:   migration.URL(data),
+		}
+	}
+	if term != "" {
+		return showQR(term, invert, codes)
+	}
+	for i, c := range codes {
+		fileName := revFile
+		if len(codes) > 1 {
+			fileName = fmt.Sprintf("%s-%d.png", strings.TrimSuffix(revFile, ".png"), i+1)
+		}
+		if err := migration.PNG(filepath.Join(workdir, fileName), c.url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printEval(p *migration.Payload, output string) error {
+	if output != "text" {
+		return writeRecords(os.Stdout, output, records(p, newEvaluation))
+	}
+	for _, op := range p.OtpParameters {
+		fmt.Println(op.EvaluateString(), op.Name)
+	}
+	return nil
+}
+
+func printInfo(p *migration.Payload, output string) error {
+	sum, err := p.Checksum()
+	if err != nil {
+		return err
+	}
+	if output != "text" {
+		return writeRecords(os.Stdout, output, []batchInfo{newBatchInfo(p, sum)})
+	}
+	fmt.Println("version", p.Version)
+	fmt.Println("batch size", p.BatchSize)
+	fmt.Println("batch index", p.BatchIndex)
+	fmt.Println("batch id", p.BatchId)
+	fmt.Println("checksum", sum)
+	return nil
+}
+
+// deprecated warns about legacy mode flag replaced by command
+func deprecated(flagName, cmd string) {
+	fmt.Fprintf(os.Stderr, "otpauth: -%s is deprecated, use \"otpauth %s\"\n", flagName, cmd)
+}
+
+// legacy runs former flag based interface
+func legacy(args []string) error {
 	var (
-		link    = flag.String("link", "", "migration link (required)")
-		workdir = flag.String("workdir", "", "working directory")
+		in      input
 		http    = flag.String("http", "", "serve http (e.g. localhost:6060)")
 		eval    = flag.Bool("eval", false, "evaluate otps")
+		live    = flag.Bool("watch", false, "live view of otps in terminal")
 		qr      = flag.Bool("qr", false, "generate QR-codes (optauth://)")
 		rev     = flag.Bool("rev", false, "reverse QR-code (otpauth-migration://)")
 		info    = flag.Bool("info", false, "display batch info")
 		dump    = flag.Bool("dump", false, "dump as prototext")
+		to      = flag.String("to", "otpauth", "output format")
+		out     = flag.String("out", "", "output file (default: stdout)")
+		pass    = flag.String("pass", "", "export pass-otp entries into directory")
+		ageFile = flag.String("age", "", "age recipients file to encrypt -pass entries for passage instead of gpg")
+		format  = flag.String("format", "", "render each account with text/template (e.g. '{{.Issuer}}\\t{{.Name}}\\t{{.Code}}')")
+		term    = flag.String("term", "", "render -qr and -rev in terminal (half, ansi)")
+		invert  = flag.Bool("invert", false, "invert terminal QR-codes for light terminals")
+		batch   = flag.Int("batch", 0, "accounts per -rev migration batch (0 for single batch)")
+		output  = flag.String("o", "text", "output of listing, -eval and -info (text, json, jsonl, csv, table)")
 	)
-	flag.Parse()
+	in.flags(flag.CommandLine, loadFlags)
+	flag.Usage = usage
+	if err := parseFlags(flag.CommandLine, args); err != nil {
+		return err
+	}
 
-	if *workdir != "" {
-		if err := os.MkdirAll(*workdir, 0700); err != nil {
-			log.Fatal("error creating working directory: ", err)
-		}
+	// flags followed by command, e.g. otpauth -link ... code query
+	if c, ok := lookupCommand(flag.Arg(0)); ok {
+		return runCommand(c, &in, flag.Args()[1:])
 	}
 
-	cacheFile := filepath.Join(*workdir, cacheFilename)
-	data, err := migrationData(cacheFile, *link)
-	if err != nil {
-		log.Fatal("-link parameter or cache file missing: ", err)
+	// modes are exclusive, only first one would run
+	var modes []string
+	for name, set := range map[string]bool{
+		"http":  *http != "",
+		"watch": *live,
+		"qr":    *qr,
+		"rev":   *rev,
+		"eval":  *eval,
+		"info":  *info,
+		"pass":  *pass != "",
+		"dump":  *dump,
+	} {
+		if set {
+			modes = append(modes, "-"+name)
+		}
+	}
+	if len(modes) > 1 {
+		sort.Strings(modes)
+		fmt.Fprintf(os.Stderr, "otpauth: mode flags %s are exclusive\n", strings.Join(modes, ", "))
+		flag.Usage()
+		return exitCode(exitUsage)
 	}
 
-	p, err := migration.Unmarshal(data)
+	p, err := in.load()
 	if err != nil {
-		log.Fatal("decode data: ", err)
+		return err
 	}
 
 	switch {
 	case *http != "":
-		if err := serve(*http, p); err != nil {
-			log.Fatal("serve http: ", err)
+		deprecated("http", "serve -addr")
+		v, err := in.accounts()
+		if err != nil {
+			return err
 		}
+		return serve(*http, v)
+	case *live:
+		deprecated("watch", "watch")
+		return watch(p)
 	case *qr:
-		for _, op := range p.OtpParameters {
-			fileName := op.FileName() + ".png"
-			qrFile := filepath.Join(*workdir, fileName)
-			if err := migration.PNG(qrFile, op.URL()); err != nil {
-				log.Fatal("write file: ", err)
-			}
-		}
+		deprecated("qr", "qr")
+		return writeQR(p, in.workdir, *term, *invert, "")
 	case *rev:
-		revFile := filepath.Join(*workdir, revFile)
-		if err := migration.PNG(revFile, migration.URL(data)); err != nil {
-			log.Fatal(err)
-		}
+		deprecated("rev", "rev")
+		return writeRev(p, in.workdir, *term, *invert, *batch)
 	case *eval:
-		for _, op := range p.OtpParameters {
-			fmt.Printf("%06d %s\n", op.Evaluate(), op.Name)
+		deprecated("eval", "eval")
+		if err := printEval(p, *output); err != nil {
+			return err
 		}
+		return in.saveCounter(p.OtpParameters...)
 	case *info:
-		fmt.Println("version", p.Version)
-		fmt.Println("batch size", p.BatchSize)
-		fmt.Println("batch index", p.BatchIndex)
-		fmt.Println("batch id", p.BatchId)
+		deprecated("info", "info")
+		return printInfo(p, *output)
+	case *pass != "":
+		deprecated("pass", "export -pass")
+		return exportPass(*pass, *ageFile, p)
 	case *dump:
-		fmt.Println(p.Pretty())
+		deprecated("dump", "dump")
+		return encode(p, "prototext", *out)
 	default:
-		for _, op := range p.OtpParameters {
-			fmt.Println(op.URL())
+		return decode(p, *to, *out, *format, *output)
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 {
+		if c, ok := lookupCommand(args[0]); ok {
+			return runCommand(c, &input{}, args[1:])
 		}
+		if args[0] == "help" {
+			args = []string{"-h"}
+		}
+	}
+	return legacy(args)
+}
+
+func main() {
+	err := run(os.Args[1:])
+	var code exitCode
+	if errors.As(err, &code) {
+		os.Exit(int(code))
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
 }
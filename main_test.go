@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+func TestQRFileNames(t *testing.T) {
+	account := func(name, issuer, secret string) *migration.Payload_OtpParameters {
+		return &migration.Payload_OtpParameters{Name: name, Issuer: issuer, Secret: []byte(secret)}
+	}
+	testCases := []struct {
+		name     string
+		accounts []*migration.Payload_OtpParameters
+		template string
+		want     []string
+	}{
+		{
+			name: "distinct",
+			accounts: []*migration.Payload_OtpParameters{
+				account("alice", "Example", "a"),
+				account("bob", "Example", "b"),
+			},
+			want: []string{"alice_Example.png", "bob_Example.png"},
+		},
+		{
+			name: "sanitized collision",
+			accounts: []*migration.Payload_OtpParameters{
+				account("a.b@x", "", "a"),
+				account("a_b@x", "", "b"),
+			},
+			want: []string{"a_b_x__e1407479.png", "a_b_x__3c480084.png"},
+		},
+		{
+			name: "case only collision",
+			accounts: []*migration.Payload_OtpParameters{
+				account("Alice", "Example", "a"),
+				account("alice", "Example", "b"),
+			},
+			want: []string{"Alice_Example_e1407479.png", "alice_Example_3c480084.png"},
+		},
+		{
+			name: "duplicate secret",
+			accounts: []*migration.Payload_OtpParameters{
+				account("alice", "Example", "a"),
+				account("alice", "Example", "a"),
+			},
+			want: []string{"alice_Example_e1407479.png", "alice_Example_e1407479_1.png"},
+		},
+		{
+			name: "template",
+			accounts: []*migration.Payload_OtpParameters{
+				account("Example:alice", "Example", "a"),
+				account("bob", "", "b"),
+			},
+			template: "{{.Issuer}}/{{.Account}}",
+			want:     []string{"Example_alice.png", "_bob.png"},
+		},
+		{
+			name: "template collision",
+			accounts: []*migration.Payload_OtpParameters{
+				account("alice", "Example", "a"),
+				account("bob", "example", "b"),
+			},
+			template: "{{.Issuer}}",
+			want:     []string{"Example_e1407479.png", "example_3c480084.png"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := qrFileNames(migration.NewPayload(tc.accounts), tc.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
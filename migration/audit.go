@@ -0,0 +1,103 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity of audit finding
+type Severity int
+
+const (
+	Low Severity = iota
+	Medium
+	High
+)
+
+var severityNames = []string{
+	Low:    "low",
+	Medium: "medium",
+	High:   "high",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity returns severity by its name
+func ParseSeverity(name string) (Severity, error) {
+	for x, v := range severityNames {
+		if strings.EqualFold(v, name) {
+			return Severity(x), nil
+		}
+	}
+	return 0, fmt.Errorf("severity %s: %w", name, ErrUnknown)
+}
+
+// Audit thresholds
+const (
+	minSecretBits  = 128  // RFC 4226 requires at least 128 bits
+	weakSecretBits = 80   // shorter than common 16 character base32 secrets
+	maxCounter     = 1000 // HOTP counter likely out of sync with server
+)
+
+// Finding of audit for single account
+type Finding struct {
+	Severity Severity
+	Check    string
+	Account  *Payload_OtpParameters
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s: %s", f.Severity, f.Account.Name, f.Check, f.Message)
+}
+
+// Audit inspects accounts for weak secrets, algorithms and inconsistent labels
+func (p *Payload) Audit() []Finding {
+	var findings []Finding
+	report := func(s Severity, check string, op *Payload_OtpParameters, format string, args ...any) {
+		findings = append(findings, Finding{
+			Severity: s,
+			Check:    check,
+			Account:  op,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	secrets := make(map[string][]*Payload_OtpParameters)
+	files := make(map[string][]*Payload_OtpParameters)
+	for _, op := range p.OtpParameters {
+		secrets[op.UUID().String()] = append(secrets[op.UUID().String()], op)
+		files[strings.ToLower(op.FileName())] = append(files[strings.ToLower(op.FileName())], op)
+	}
+	for _, op := range p.OtpParameters {
+		switch bits := 8 * len(op.Secret); {
+		case bits < weakSecretBits:
+			report(High, "short-secret", op, "secret has %d bits, want at least %d", bits, minSecretBits)
+		case bits < minSecretBits:
+			report(Medium, "short-secret", op, "secret has %d bits, want at least %d", bits, minSecretBits)
+		}
+		if op.Algorithm == Payload_OtpParameters_ALGORITHM_MD5 {
+			report(High, "md5", op, "MD5 algorithm is broken and poorly supported")
+		}
+		for _, x := range secrets[op.UUID().String()] {
+			if x != op {
+				report(Medium, "duplicate-secret", op, "secret is shared with %s", x.Name)
+			}
+		}
+		if op.Issuer == "" {
+			report(Low, "missing-issuer", op, "issuer is empty")
+		} else if prefix, _, ok := strings.Cut(op.Name, ":"); ok && prefix != op.Issuer {
+			report(Low, "issuer-mismatch", op, "label prefix %q differs from issuer %q", prefix, op.Issuer)
+		}
+		if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP && op.Counter > maxCounter {
+			report(Low, "hotp-counter", op, "counter %d is far from zero", op.Counter)
+		}
+		for _, x := range files[strings.ToLower(op.FileName())] {
+			if x != op {
+				report(Medium, "filename-collision", op, "file name %s collides with %s", op.FileName(), x.Name)
+			}
+		}
+	}
+	return findings
+}
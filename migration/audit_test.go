@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	strong := make([]byte, 20)
+	p := NewPayload([]*Payload_OtpParameters{
+		{Secret: strong, Name: "GitHub:alice", Issuer: "GitHub"},
+		{Secret: []byte("0123456789ab"), Name: "Example:bob", Issuer: "Example", Algorithm: Payload_OtpParameters_ALGORITHM_MD5},
+		{Secret: []byte("short"), Name: "carol"},
+		{Secret: []byte("0123456789abcdef"), Name: "Gitlab:dave", Issuer: "GitLab", Type: Payload_OtpParameters_OTP_TYPE_HOTP, Counter: 5000},
+		{Secret: []byte("fedcba9876543210"), Name: "Example/bob", Issuer: "Example"},
+	})
+	got := make(map[string][]string)
+	for _, f := range p.Audit() {
+		got[f.Account.Name] = append(got[f.Account.Name], f.Severity.String()+" "+f.Check)
+	}
+	want := map[string][]string{
+		"Example:bob": {"medium short-secret", "high md5", "medium filename-collision"},
+		"carol":       {"high short-secret", "low missing-issuer"},
+		"Gitlab:dave": {"low issuer-mismatch", "low hotp-counter"},
+		"Example/bob": {"medium filename-collision"},
+	}
+	for name, w := range want {
+		if !slices.Equal(got[name], w) {
+			t.Errorf("%s: got %q; want %q", name, got[name], w)
+		}
+	}
+	if len(got["GitHub:alice"]) != 0 {
+		t.Errorf("got %q for strong account", got["GitHub:alice"])
+	}
+}
+
+func TestAuditDuplicate(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	p := NewPayload([]*Payload_OtpParameters{
+		{Secret: secret, Name: "a", Issuer: "A"},
+		{Secret: secret, Name: "b", Issuer: "B"},
+	})
+	findings := p.Audit()
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings; want 2", len(findings))
+	}
+	for i, want := range []string{"b", "a"} {
+		f := findings[i]
+		if f.Check != "duplicate-secret" || f.Severity != Medium {
+			t.Errorf("got %v; want medium duplicate-secret", f)
+		}
+		if !strings.HasSuffix(f.Message, want) {
+			t.Errorf("got %q; want shared with %s", f.Message, want)
+		}
+	}
+}
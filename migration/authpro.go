@@ -0,0 +1,135 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// authProHeader prefixes encrypted Authenticator Pro backups
+const authProHeader = "AUTHENTICATORPRO"
+
+func init() {
+	Register("authpro", FormatFuncs{
+		DecodeFunc: UnmarshalAuthPro,
+		EncodeFunc: (*Payload).AuthPro,
+		DetectFunc: func(data []byte) bool {
+			return bytes.HasPrefix(data, []byte(authProHeader)) ||
+				bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) &&
+					bytes.Contains(data, []byte(`"Authenticators"`))
+		},
+	}, ".authpro")
+}
+
+var authProAlgorithms = []Payload_OtpParameters_Algorithm{
+	Payload_OtpParameters_ALGORITHM_SHA1,
+	Payload_OtpParameters_ALGORITHM_SHA256,
+	Payload_OtpParameters_ALGORITHM_SHA512,
+}
+
+const (
+	authProHOTP = 1
+	authProTOTP = 2
+)
+
+type authProAuthenticator struct {
+	Type      int     `json:"Type"`
+	Icon      *string `json:"Icon"`
+	Issuer    string  `json:"Issuer"`
+	Username  string  `json:"Username"`
+	Secret    string  `json:"Secret"`
+	Pin       *string `json:"Pin"`
+	Algorithm int     `json:"Algorithm"`
+	Digits    int     `json:"Digits"`
+	Period    int     `json:"Period"`
+	Counter   uint64  `json:"Counter"`
+	CopyCount int     `json:"CopyCount"`
+	Ranking   int     `json:"Ranking"`
+}
+
+type authProBackup struct {
+	Authenticators          []authProAuthenticator `json:"Authenticators"`
+	Categories              []json.RawMessage      `json:"Categories"`
+	AuthenticatorCategories []json.RawMessage      `json:"AuthenticatorCategories"`
+	CustomIcons             []json.RawMessage      `json:"CustomIcons"`
+}
+
+// UnmarshalAuthPro reads unencrypted Authenticator Pro (Stratum) backup
+func UnmarshalAuthPro(data []byte) (*Payload, error) {
+	if bytes.HasPrefix(data, []byte(authProHeader)) {
+		return nil, fmt.Errorf("encrypted backup: %w", ErrUnsupported)
+	}
+	var b authProBackup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	ops := make([]*Payload_OtpParameters, len(b.Authenticators))
+	for i, a := range b.Authenticators {
+		if err := checkPeriod(a.Period); err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Username, err)
+		}
+		secret, err := decodeSecret(a.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("%s: secret: %w", a.Username, err)
+		}
+		op := &Payload_OtpParameters{
+			Secret:  secret,
+			Name:    label(a.Issuer, a.Username),
+			Issuer:  a.Issuer,
+			Counter: a.Counter,
+		}
+		if a.Algorithm < 0 || a.Algorithm >= len(authProAlgorithms) {
+			return nil, fmt.Errorf("%s: algorithm %d: %w", a.Username, a.Algorithm, ErrUnsupported)
+		}
+		op.Algorithm = authProAlgorithms[a.Algorithm]
+		if op.Digits, err = ParseDigits(a.Digits); err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Username, err)
+		}
+		switch a.Type {
+		case authProHOTP:
+			op.Type = Payload_OtpParameters_OTP_TYPE_HOTP
+		case authProTOTP:
+			op.Type = Payload_OtpParameters_OTP_TYPE_TOTP
+		default:
+			return nil, fmt.Errorf("%s: type %d: %w", a.Username, a.Type, ErrUnsupported)
+		}
+		ops[i] = op
+	}
+	return NewPayload(ops), nil
+}
+
+// AuthPro returns unencrypted Authenticator Pro (Stratum) backup
+func (p *Payload) AuthPro() ([]byte, error) {
+	b := authProBackup{
+		Authenticators:          make([]authProAuthenticator, len(p.OtpParameters)),
+		Categories:              []json.RawMessage{},
+		AuthenticatorCategories: []json.RawMessage{},
+		CustomIcons:             []json.RawMessage{},
+	}
+	for i, op := range p.OtpParameters {
+		a := authProAuthenticator{
+			Type:      authProTOTP,
+			Issuer:    op.Issuer,
+			Username:  op.Account(),
+			Secret:    op.SecretString(),
+			Algorithm: -1,
+			Digits:    op.Digits.Count(),
+			Period:    int(period.Seconds()),
+			Ranking:   i,
+		}
+		if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP {
+			a.Type = authProHOTP
+			a.Counter = op.Counter
+		}
+		for x, v := range authProAlgorithms {
+			if v.Name() == op.Algorithm.Name() {
+				a.Algorithm = x
+			}
+		}
+		if a.Algorithm < 0 {
+			return nil, fmt.Errorf("%s: algorithm %s: %w", a.Username, op.Algorithm.Name(), ErrUnsupported)
+		}
+		b.Authenticators[i] = a
+	}
+	return json.MarshalIndent(b, "", "  ")
+}
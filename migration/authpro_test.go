@@ -0,0 +1,32 @@
+package migration
+
+import "testing"
+
+func TestAuthPro(t *testing.T) {
+	const testData = `{"Authenticators":[{"Type":1,"Icon":null,"Issuer":"Example","Username":"alice@google.com","Secret":"JBSWY3DPEHPK3PXP","Pin":null,"Algorithm":1,"Digits":8,"Period":30,"Counter":3,"CopyCount":0,"Ranking":0}],"Categories":[],"AuthenticatorCategories":[],"CustomIcons":[]}`
+	const want = "otpauth://hotp/Example:alice@google.com?algorithm=SHA256&counter=3&digits=8&issuer=Example&secret=JBSWY3DPEHPK3PXP"
+	p, err := UnmarshalAuthPro([]byte(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.OtpParameters) != 1 {
+		t.Fatalf("got length %v, want 1", len(p.OtpParameters))
+	}
+	if got := p.OtpParameters[0].URL().String(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	b, err := p.AuthPro()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := UnmarshalAuthPro(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.OtpParameters[0].URL().String(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := UnmarshalAuthPro([]byte(authProHeader + "...")); err == nil {
+		t.Error("want error on encrypted backup")
+	}
+}
@@ -0,0 +1,28 @@
+package migration
+
+import "math/rand/v2"
+
+// Batches splits payload into batches of at most n accounts,
+// as Google Authenticator does for large exports
+func (p *Payload) Batches(n int) []*Payload {
+	if n <= 0 || len(p.OtpParameters) <= n {
+		return []*Payload{p}
+	}
+	id := p.BatchId
+	if id == 0 {
+		id = rand.Int32()
+	}
+	size := (len(p.OtpParameters) + n - 1) / n
+	batches := make([]*Payload, size)
+	for i := range batches {
+		ops := p.OtpParameters[i*n : min((i+1)*n, len(p.OtpParameters))]
+		batches[i] = &Payload{
+			OtpParameters: ops,
+			Version:       p.Version,
+			BatchSize:     int32(size),
+			BatchIndex:    int32(i),
+			BatchId:       id,
+		}
+	}
+	return batches
+}
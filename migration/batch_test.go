@@ -0,0 +1,22 @@
+package migration
+
+import "testing"
+
+func TestBatches(t *testing.T) {
+	p := NewPayload(make([]*Payload_OtpParameters, 25))
+	batches := p.Batches(10)
+	if len(batches) != 3 {
+		t.Fatalf("got %v batches, want 3", len(batches))
+	}
+	for i, b := range batches {
+		if b.BatchIndex != int32(i) || b.BatchSize != 3 || b.BatchId != batches[0].BatchId {
+			t.Errorf("got index %v size %v id %v", b.BatchIndex, b.BatchSize, b.BatchId)
+		}
+	}
+	if n := len(batches[2].OtpParameters); n != 5 {
+		t.Errorf("got %v accounts in last batch, want 5", n)
+	}
+	if got := p.Batches(0); len(got) != 1 || got[0] != p {
+		t.Errorf("got %v, want payload itself", got)
+	}
+}
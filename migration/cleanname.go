@@ -1,20 +1,33 @@
 package migration
 
 import (
+	"path"
 	"strings"
 	"unicode"
 
 	"github.com/google/uuid"
 )
 
-// FileName returns sanitized filename without path delimiters
-func (op *Payload_OtpParameters) FileName() string {
+func cleanName(s string) string {
 	return strings.Map(func(r rune) rune {
 		if unicode.IsLetter(r) || unicode.IsNumber(r) {
 			return r
 		}
 		return '_'
-	}, op.Name+"_"+op.Issuer)
+	}, s)
+}
+
+// FileName returns sanitized filename without path delimiters
+func (op *Payload_OtpParameters) FileName() string {
+	return cleanName(op.Name + "_" + op.Issuer)
+}
+
+// PassName returns sanitized issuer/name hierarchy for password stores
+func (op *Payload_OtpParameters) PassName() string {
+	if op.Issuer == "" {
+		return cleanName(op.Account())
+	}
+	return path.Join(cleanName(op.Issuer), cleanName(op.Account()))
 }
 
 // UUID of OTP parameter
@@ -1,11 +1,25 @@
 package migration
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/base32"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 )
 
+func init() {
+	Register("otpauth", FormatFuncs{
+		DecodeFunc: unmarshalURLs,
+		EncodeFunc: marshalURLs,
+		DetectFunc: func(data []byte) bool {
+			return bytes.HasPrefix(bytes.TrimSpace(data), []byte("otpauth://"))
+		},
+	})
+}
+
 // SecretString returns Secret as a base32 encoded String
 func (op *Payload_OtpParameters) SecretString() string {
 	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(op.Secret)
@@ -14,14 +28,29 @@ func (op *Payload_OtpParameters) SecretString() string {
 // SecretTuples returns Secret as a base32 string splitted into tuples of 4
 func (op *Payload_OtpParameters) SecretTuples() []string {
 	secret := op.SecretString()
-	// pad secret to multiple of 4×4
-	tuples := make([]string, len(secret)/4)
-	for i := range tuples {
-		tuples[i] = secret[4*i : 4*i+4]
+	var tuples []string
+	for i := 0; i < len(secret); i += 4 {
+		tuples = append(tuples, secret[i:min(i+4, len(secret))])
 	}
 	return tuples
 }
 
+// Account returns Name without Issuer prefix
+func (op *Payload_OtpParameters) Account() string {
+	if op.Issuer == "" {
+		return op.Name
+	}
+	return strings.TrimPrefix(op.Name, op.Issuer+":")
+}
+
+// label joins issuer and account the way Name is usually formed
+func label(issuer, account string) string {
+	if issuer == "" {
+		return account
+	}
+	return issuer + ":" + account
+}
+
 // URL of otp parameters
 func (op *Payload_OtpParameters) URL() *url.URL {
 	v := make(url.Values)
@@ -54,3 +83,89 @@ func (op *Payload_OtpParameters) URL() *url.URL {
 		RawQuery: v.Encode(),
 	}
 }
+
+// ParseURL parses plain otpauth URL into otp parameters
+func ParseURL(link string) (*Payload_OtpParameters, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("scheme %s: %w", u.Scheme, ErrUnknown)
+	}
+	v := u.Query()
+	op := &Payload_OtpParameters{
+		Name:   strings.TrimPrefix(u.Path, "/"),
+		Issuer: v.Get("issuer"),
+	}
+	switch u.Host {
+	case "totp":
+		op.Type = Payload_OtpParameters_OTP_TYPE_TOTP
+	case "hotp":
+		op.Type = Payload_OtpParameters_OTP_TYPE_HOTP
+	default:
+		return nil, fmt.Errorf("host %s: %w", u.Host, ErrUnknown)
+	}
+	if op.Secret, err = decodeSecret(v.Get("secret")); err != nil {
+		return nil, fmt.Errorf("secret: %w", err)
+	}
+	if s := v.Get("algorithm"); s != "" {
+		if op.Algorithm, err = ParseAlgorithm(s); err != nil {
+			return nil, err
+		}
+	}
+	if s := v.Get("digits"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("digits: %w", err)
+		}
+		if op.Digits, err = ParseDigits(n); err != nil {
+			return nil, err
+		}
+	}
+	if s := v.Get("counter"); s != "" {
+		if op.Counter, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return nil, fmt.Errorf("counter: %w", err)
+		}
+	}
+	if s := v.Get("period"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("period: %w", err)
+		}
+		if err := checkPeriod(n); err != nil {
+			return nil, err
+		}
+	}
+	return op, nil
+}
+
+// unmarshalURLs reads plain otpauth URLs, one per line
+func unmarshalURLs(data []byte) (*Payload, error) {
+	var ops []*Payload_OtpParameters
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		op, err := ParseURL(line)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return NewPayload(ops), nil
+}
+
+// marshalURLs writes plain otpauth URLs, one per line
+func marshalURLs(p *Payload) ([]byte, error) {
+	var b bytes.Buffer
+	for _, op := range p.OtpParameters {
+		fmt.Fprintln(&b, op.URL())
+	}
+	return b.Bytes(), nil
+}
@@ -28,3 +28,19 @@ func TestStdBase64(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestLinks(t *testing.T) {
+	const testData = "QR-Code:otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC  \r\n\n" +
+		"  otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy+dfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg==\n"
+	links := Links([]byte(testData))
+	if len(links) != 2 {
+		t.Fatalf("got %v links, want 2", len(links))
+	}
+	p, err := UnmarshalLinks([]byte(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.OtpParameters) != 2 {
+		t.Errorf("got %v accounts, want 2", len(p.OtpParameters))
+	}
+}
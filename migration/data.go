@@ -0,0 +1,143 @@
+package migration
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Normalization applied to mangled migration links
+type Normalization uint
+
+const (
+	NormWrapped   Normalization = 1 << iota // line breaks removed
+	NormUnescaped                           // percent-encoded more than once
+	NormSpaces                              // spaces restored to plus signs
+	NormURLSafe                             // URL-safe alphabet converted
+	NormUnpadded                            // missing padding added
+)
+
+var normNames = []string{"wrapped", "unescaped", "spaces", "url-safe", "unpadded"}
+
+func (n Normalization) String() string {
+	if n == 0 {
+		return "none"
+	}
+	var s []string
+	for i, name := range normNames {
+		if n&(1<<i) != 0 {
+			s = append(s, name)
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+// DecodeError of migration data at offset of normalized data
+type DecodeError struct {
+	Offset int
+	Reason string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("data offset %d: %s", e.Offset, e.Reason)
+}
+
+// isWrapped reports whether line looks like continuation of wrapped data
+func isWrapped(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && strings.Trim(line, base64Chars+"%-_ ") == ""
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="
+
+// queryValue returns raw, still escaped value of key
+func queryValue(rawQuery, key string) string {
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if k, v, _ := strings.Cut(kv, "="); k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// unescape percent-encoding repeatedly, plus signs are kept
+func unescape(s string) (string, int) {
+	var n int
+	for strings.Contains(s, "%") {
+		v, err := url.PathUnescape(s)
+		if err != nil || v == s {
+			break
+		}
+		s, n = v, n+1
+	}
+	return s, n
+}
+
+// DataNormalized extracts data part from URL string, repairing links
+// which are wrapped, percent-encoded twice, URL-safe, unpadded or had
+// plus signs turned into spaces. It reports normalizations applied.
+func DataNormalized(link string) ([]byte, Normalization, error) {
+	var norm Normalization
+	link = strings.TrimSpace(link)
+	if strings.ContainsAny(link, "\r\n\t") {
+		link = strings.NewReplacer("\r", "", "\n", "", "\t", "").Replace(link)
+		norm |= NormWrapped
+	}
+	if !strings.Contains(link, "://") {
+		// whole link escaped
+		if v, n := unescape(link); n > 0 {
+			link = v
+			norm |= NormUnescaped
+		}
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, norm, err
+	}
+	if u.Scheme != "otpauth-migration" {
+		return nil, norm, fmt.Errorf("scheme %s: %w", u.Scheme, ErrUnknown)
+	}
+	if u.Host != "offline" {
+		return nil, norm, fmt.Errorf("host %s: %w", u.Host, ErrUnknown)
+	}
+	data, n := unescape(queryValue(u.RawQuery, "data"))
+	if n > 1 {
+		norm |= NormUnescaped
+	}
+	if strings.Contains(data, " ") {
+		// fix spaces back to plus sign
+		data = strings.ReplaceAll(data, " ", "+")
+		norm |= NormSpaces
+	}
+	if strings.ContainsAny(data, "-_") {
+		data = strings.NewReplacer("-", "+", "_", "/").Replace(data)
+		norm |= NormURLSafe
+	}
+	if r := len(data) % 4; r != 0 && !strings.HasSuffix(data, "=") {
+		data += strings.Repeat("=", 4-r)
+		norm |= NormUnpadded
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		if off, ok := err.(base64.CorruptInputError); ok {
+			return nil, norm, decodeError(data, int(off))
+		}
+		return nil, norm, err
+	}
+	return b, norm, nil
+}
+
+func decodeError(data string, off int) *DecodeError {
+	if off >= len(data) || data[off] == '=' {
+		return &DecodeError{Offset: off, Reason: "truncated or misplaced padding"}
+	}
+	return &DecodeError{Offset: off, Reason: fmt.Sprintf("illegal character %q near %q",
+		data[off], data[max(0, off-8):min(len(data), off+8)])}
+}
+
+// Data extracts data part from URL string
+func Data(link string) ([]byte, error) {
+	data, _, err := DataNormalized(link)
+	return data, err
+}
@@ -0,0 +1,109 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDataNormalized(t *testing.T) {
+	const testData = "otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy+dfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg=="
+	want, err := Data(testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// real-world mangled variants of the same link
+	testCases := []struct {
+		name string
+		link string
+		norm Normalization
+	}{
+		{
+			name: "plain",
+			link: testData,
+		},
+		{
+			name: "escaped",
+			link: "otpauth-migration://offline?data=CjsKFBHMQnKu%2FodWlB%2FzUy%2BdfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg%3D%3D",
+		},
+		{
+			name: "escaped twice",
+			link: "otpauth-migration://offline?data=CjsKFBHMQnKu%252FodWlB%252FzUy%252BdfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg%253D%253D",
+			norm: NormUnescaped,
+		},
+		{
+			name: "whole link escaped",
+			link: "otpauth-migration%3A%2F%2Foffline%3Fdata%3DCjsKFBHMQnKu%2FodWlB%2FzUy%2BdfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg%3D%3D",
+			norm: NormUnescaped,
+		},
+		{
+			name: "spaces",
+			link: "otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy dfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg==",
+			norm: NormSpaces,
+		},
+		{
+			name: "url-safe unpadded",
+			link: "otpauth-migration://offline?data=CjsKFBHMQnKu_odWlB_zUy-dfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg",
+			norm: NormURLSafe | NormUnpadded,
+		},
+		{
+			name: "wrapped",
+			link: "otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy+dfiRIaHj0Ehh\r\nFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg==\n",
+			norm: NormWrapped,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, norm, err := DataNormalized(tc.link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if norm != tc.norm {
+				t.Errorf("got normalization %v; want %v", norm, tc.norm)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %x; want %x", got, want)
+			}
+		})
+	}
+}
+
+func TestDataError(t *testing.T) {
+	_, _, err := DataNormalized("otpauth-migration://offline?data=CjsKFBHMQnKu!odWlB")
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("got %v; want DecodeError", err)
+	}
+	if de.Offset != 12 {
+		t.Errorf("got offset %v; want 12", de.Offset)
+	}
+}
+
+func TestLinksWrapped(t *testing.T) {
+	const testData = "QR-Code:otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy+dfiRIaHj0Ehh\nFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg==\n"
+	links := Links([]byte(testData))
+	if len(links) != 1 {
+		t.Fatalf("got %v links, want 1", len(links))
+	}
+	if _, err := Data(links[0]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLinksOtherLines(t *testing.T) {
+	const link = "otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy+dfiRIaHj0EhhFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg=="
+	const testData = link + "\nbackup2024\n\n" +
+		"otpauth-migration://offline?data=CjsKFBHMQnKu/odWlB/zUy+dfiRIaHj0Ehh\n# comment\nFeGFtcGxlOmFsaWNlQGdvb2dsZS5jb20aB0V4YW1wbGUwAg==\n"
+	links := Links([]byte(testData))
+	if len(links) != 2 {
+		t.Fatalf("got %v links, want 2", len(links))
+	}
+	// complete link is not continued, incomplete one only by adjacent lines
+	if links[0] != link {
+		t.Errorf("got %q; want %q", links[0], link)
+	}
+	if strings.HasSuffix(links[1], "Ag==") {
+		t.Errorf("got %q joined across comment", links[1])
+	}
+}
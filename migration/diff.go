@@ -0,0 +1,64 @@
+package migration
+
+// ChangeKind of account between two payloads
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Changed
+)
+
+var changeKindNames = []string{
+	Added:   "added",
+	Removed: "removed",
+	Changed: "changed",
+}
+
+func (k ChangeKind) String() string {
+	return changeKindNames[k]
+}
+
+// Change of single account, Old is nil for added and New for removed accounts
+type Change struct {
+	Kind     ChangeKind
+	Old, New *Payload_OtpParameters
+	Fields   []string
+}
+
+// Diff reports accounts added, removed and changed from a to b. Accounts
+// match by unique id if both have one, by secret otherwise.
+func Diff(a, b *Payload) []Change {
+	var changes []Change
+	matched := make(map[*Payload_OtpParameters]bool)
+	match := func(op *Payload_OtpParameters) *Payload_OtpParameters {
+		for _, x := range b.OtpParameters {
+			if !matched[x] && op.UniqueId != "" && op.UniqueId == x.UniqueId {
+				return x
+			}
+		}
+		for _, x := range b.OtpParameters {
+			if !matched[x] && op.UUID() == x.UUID() {
+				return x
+			}
+		}
+		return nil
+	}
+	for _, op := range a.OtpParameters {
+		x := match(op)
+		if x == nil {
+			changes = append(changes, Change{Kind: Removed, Old: op})
+			continue
+		}
+		matched[x] = true
+		if fields := Changes(op, x); len(fields) > 0 {
+			changes = append(changes, Change{Kind: Changed, Old: op, New: x, Fields: fields})
+		}
+	}
+	for _, x := range b.OtpParameters {
+		if !matched[x] {
+			changes = append(changes, Change{Kind: Added, New: x})
+		}
+	}
+	return changes
+}
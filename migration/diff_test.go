@@ -0,0 +1,39 @@
+package migration
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte{1}, Name: "GitHub:alice", Issuer: "GitHub"},
+		{Secret: []byte{2}, Name: "bob", Type: Payload_OtpParameters_OTP_TYPE_HOTP, Counter: 3},
+		{Secret: []byte{3}, Name: "carol", UniqueId: "id3"},
+		{Secret: []byte{4}, Name: "dave"},
+	})
+	b := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte{1}, Name: "GitHub:alice", Issuer: "GitHub"},
+		{Secret: []byte{2}, Name: "bob", Type: Payload_OtpParameters_OTP_TYPE_HOTP, Counter: 5},
+		{Secret: []byte{9}, Name: "carol", UniqueId: "id3"},
+		{Secret: []byte{5}, Name: "erin"},
+	})
+	var got []string
+	for _, c := range Diff(a, b) {
+		op := c.New
+		if op == nil {
+			op = c.Old
+		}
+		got = append(got, fmt.Sprint(c.Kind, " ", op.Name, " ", c.Fields))
+	}
+	want := []string{
+		"changed bob [counter]",
+		"changed carol [secret]",
+		"removed dave []",
+		"added erin []",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
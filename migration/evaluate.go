@@ -15,6 +15,14 @@ const (
 
 var now = func() time.Time { return time.Now().Add(offset) }
 
+// checkPeriod rejects periods other than default one, 0 means default
+func checkPeriod(seconds int) error {
+	if seconds != 0 && seconds != int(period.Seconds()) {
+		return fmt.Errorf("period %d: %w", seconds, ErrUnsupported)
+	}
+	return nil
+}
+
 func hotp(op *Payload_OtpParameters) uint64 {
 	op.Counter++ // pre-increment rfc4226 section 7.2.
 	return op.Counter
@@ -29,17 +37,64 @@ func (op *Payload_OtpParameters) Seconds() float64 {
 	return now().Sub(now().Truncate(period)).Seconds()
 }
 
-// Evaluate OTP parameters
-func (op *Payload_OtpParameters) Evaluate() int {
+// Period of validity frame in seconds, 0 for counter based OTPs
+func (op *Payload_OtpParameters) Period() int {
+	if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP {
+		return 0
+	}
+	return int(period.Seconds())
+}
+
+// Remaining seconds of current validity frame
+func (op *Payload_OtpParameters) Remaining() int {
+	if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP {
+		return 0
+	}
+	return int(math.Ceil(period.Seconds() - op.Seconds()))
+}
+
+// Valid seconds of current code in real time, codes are evaluated ahead by
+// offset, so they stay valid that much longer than Remaining
+func (op *Payload_OtpParameters) Valid() int {
+	if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP {
+		return 0
+	}
+	return op.Remaining() + int(offset.Seconds())
+}
+
+func (op *Payload_OtpParameters) evaluate(counter uint64) int {
 	h := hmac.New(op.Algorithm.Hash(), op.Secret)
-	binary.Write(h, binary.BigEndian, op.Type.Count(op))
+	binary.Write(h, binary.BigEndian, counter)
 	hashed := h.Sum(nil)
 	offset := hashed[h.Size()-1] & 15
 	result := binary.BigEndian.Uint32(hashed[offset:]) & (1<<31 - 1)
 	return int(result) % int(math.Pow10(op.Digits.Count()))
 }
 
+func (op *Payload_OtpParameters) format(code int) string {
+	return fmt.Sprintf("%0*d", op.Digits.Count(), code)
+}
+
+// Evaluate OTP parameters
+func (op *Payload_OtpParameters) Evaluate() int {
+	return op.evaluate(op.Type.Count(op))
+}
+
 // EvaluateString returns OTP as formatted string
 func (op *Payload_OtpParameters) EvaluateString() string {
-	return fmt.Sprintf("%0*d", op.Digits.Count(), op.Evaluate())
+	return op.format(op.Evaluate())
+}
+
+// Next returns following OTP without advancing HOTP counter:
+// of next validity frame for TOTP, of next counter for HOTP
+func (op *Payload_OtpParameters) Next() int {
+	if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP {
+		return op.evaluate(op.Counter + 1)
+	}
+	return op.evaluate(totp(op) + 1)
+}
+
+// NextString returns following OTP as formatted string
+func (op *Payload_OtpParameters) NextString() string {
+	return op.format(op.Next())
 }
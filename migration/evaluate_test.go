@@ -21,3 +21,25 @@ func TestEvaluate(t *testing.T) {
 		t.Errorf("got %v", res)
 	}
 }
+
+func TestNext(t *testing.T) {
+	// fake time, one validity frame apart
+	now = func() time.Time { return time.Date(2009, time.November, 10, 22, 59, 30, 0, time.UTC) }
+	op := &Payload_OtpParameters{Secret: []byte("Hello!\xde\xad\xbe\xef")}
+	if res := op.Next(); res != 528064 {
+		t.Errorf("got %v", res)
+	}
+	op.Type = Payload_OtpParameters_OTP_TYPE_HOTP
+	if next, res := op.Next(), op.Evaluate(); next != res {
+		t.Errorf("got next %v, evaluate %v", next, res)
+	}
+}
+
+func TestValid(t *testing.T) {
+	// fake time with offset applied, 20s into frame
+	now = func() time.Time { return time.Date(2009, time.November, 10, 23, 0, 20, 0, time.UTC) }
+	op := &Payload_OtpParameters{Secret: []byte("Hello!\xde\xad\xbe\xef")}
+	if r, v := op.Remaining(), op.Valid(); r != 10 || v != 15 {
+		t.Errorf("got remaining %v, valid %v; want 10, 15", r, v)
+	}
+}
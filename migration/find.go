@@ -0,0 +1,73 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound no account matches query
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguous several accounts match query
+	ErrAmbiguous = errors.New("ambiguous")
+)
+
+// subsequence reports whether all runes of q appear in s in order
+func subsequence(s, q string) bool {
+	for _, r := range s {
+		if q == "" {
+			break
+		}
+		if strings.HasPrefix(q, string(r)) {
+			q = q[len(string(r)):]
+		}
+	}
+	return q == ""
+}
+
+// matchers of labels and UUID, from strict to loose
+var matchers = []struct {
+	label, uuid func(s, q string) bool
+}{
+	{
+		label: func(s, q string) bool { return s == q },
+		uuid:  func(s, q string) bool { return s == q },
+	},
+	{label: strings.Contains, uuid: strings.HasPrefix},
+	{label: subsequence, uuid: func(s, q string) bool { return false }},
+}
+
+// Find returns single account matching query by UUID, name or issuer,
+// trying exact, substring and fuzzy matches in that order
+func (p *Payload) Find(query string) (*Payload_OtpParameters, error) {
+	q := strings.ToLower(query)
+	for _, match := range matchers {
+		var found []*Payload_OtpParameters
+		for _, op := range p.OtpParameters {
+			if match.uuid(op.UUID().String(), q) {
+				found = append(found, op)
+				continue
+			}
+			for _, s := range []string{op.Name, op.Account(), op.Issuer} {
+				if s != "" && match.label(strings.ToLower(s), q) {
+					found = append(found, op)
+					break
+				}
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			names := make([]string, len(found))
+			for i, op := range found {
+				names[i] = op.Name
+			}
+			return nil, fmt.Errorf("%q matches %s: %w", query, strings.Join(names, ", "), ErrAmbiguous)
+		}
+	}
+	return nil, fmt.Errorf("%q: %w", query, ErrNotFound)
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	p := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte{1}, Name: "GitHub:alice", Issuer: "GitHub"},
+		{Secret: []byte{2}, Name: "GitLab:alice", Issuer: "GitLab"},
+		{Secret: []byte{3}, Name: "Example:bob@example.com", Issuer: "Example"},
+	})
+	testCases := []struct {
+		query string
+		want  string
+		err   error
+	}{
+		{query: "github", want: "GitHub:alice"},
+		{query: "lab", want: "GitLab:alice"},
+		{query: "exbob", want: "Example:bob@example.com"},
+		{query: p.OtpParameters[2].UUID().String()[:8], want: "Example:bob@example.com"},
+		{query: "alice", err: ErrAmbiguous},
+		{query: "nobody", err: ErrNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			op, err := p.Find(tc.query)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v; want %v", err, tc.err)
+			}
+			if err == nil && op.Name != tc.want {
+				t.Errorf("got %v; want %v", op.Name, tc.want)
+			}
+		})
+	}
+}
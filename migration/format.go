@@ -0,0 +1,121 @@
+package migration
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format converts payload from and to external representation
+type Format interface {
+	Decode(data []byte) (*Payload, error)
+	Encode(p *Payload) ([]byte, error)
+}
+
+// Detector is optionally implemented by formats recognizable by content
+type Detector interface {
+	Detect(data []byte) bool
+}
+
+// FormatFuncs adapts plain functions to Format and Detector,
+// nil functions are reported as unsupported
+type FormatFuncs struct {
+	DecodeFunc func(data []byte) (*Payload, error)
+	EncodeFunc func(p *Payload) ([]byte, error)
+	DetectFunc func(data []byte) bool
+}
+
+// Decode calls DecodeFunc
+func (f FormatFuncs) Decode(data []byte) (*Payload, error) {
+	if f.DecodeFunc == nil {
+		return nil, fmt.Errorf("decode: %w", ErrUnsupported)
+	}
+	return f.DecodeFunc(data)
+}
+
+// Encode calls EncodeFunc
+func (f FormatFuncs) Encode(p *Payload) ([]byte, error) {
+	if f.EncodeFunc == nil {
+		return nil, fmt.Errorf("encode: %w", ErrUnsupported)
+	}
+	return f.EncodeFunc(p)
+}
+
+// Detect calls DetectFunc
+func (f FormatFuncs) Detect(data []byte) bool {
+	return f.DetectFunc != nil && f.DetectFunc(data)
+}
+
+type registered struct {
+	name string
+	exts []string
+	Format
+}
+
+var registry []registered
+
+// Register makes format available by name and file extensions.
+// It panics if name is already registered.
+func Register(name string, f Format, exts ...string) {
+	for _, r := range registry {
+		if r.name == name {
+			panic("migration: format registered twice: " + name)
+		}
+	}
+	registry = append(registry, registered{name: name, exts: exts, Format: f})
+}
+
+// Lookup format by name
+func Lookup(name string) (Format, error) {
+	for _, r := range registry {
+		if r.name == name {
+			return r.Format, nil
+		}
+	}
+	return nil, fmt.Errorf("format %s: %w", name, ErrUnknown)
+}
+
+// Formats returns sorted names of registered formats
+func Formats() []string {
+	names := make([]string, len(registry))
+	for i, r := range registry {
+		names[i] = r.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect format name by file extension or, failing that, by content
+func Detect(filename string, data []byte) (string, error) {
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != "" {
+		for _, r := range registry {
+			for _, v := range r.exts {
+				if v == ext {
+					return r.name, nil
+				}
+			}
+		}
+	}
+	for _, r := range registry {
+		if d, ok := r.Format.(Detector); ok && d.Detect(data) {
+			return r.name, nil
+		}
+	}
+	return "", fmt.Errorf("format of %s: %w", filename, ErrUnknown)
+}
+
+// Decode data of named format, empty name means auto-detection
+func Decode(name, filename string, data []byte) (*Payload, error) {
+	if name == "" {
+		var err error
+		if name, err = Detect(filename, data); err != nil {
+			return nil, err
+		}
+	}
+	f, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decode(data)
+}
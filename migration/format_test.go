@@ -0,0 +1,81 @@
+package migration
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		filename string
+		data     string
+		want     string
+	}{
+		{
+			filename: "-",
+			data:     "otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC\n",
+			want:     "link",
+		},
+		{
+			filename: "-",
+			data:     "otpauth://totp/Example:alice@google.com?issuer=Example&period=30&secret=JBSWY3DPEHPK3PXP\n",
+			want:     "otpauth",
+		},
+		{
+			filename: "-",
+			data:     "JBSWY3DPEHPK3PXP\n\" TOTP_AUTH\n",
+			want:     "google-authenticator",
+		},
+		{
+			filename: "-",
+			data:     "# users\nHOTP/T30\talice\t-\t48656c6c6f21deadbeef\n",
+			want:     "oath",
+		},
+		{
+			filename: "backup.authpro",
+			data:     "{}",
+			want:     "authpro",
+		},
+		{
+			filename: "migration.bin",
+			want:     "migration",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			got, err := Detect(tc.filename, []byte(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	const testData = "otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC"
+	p, err := UnmarshalURL(testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := p.OtpParameters[0]
+	for _, name := range []string{"otpauth", "link", "migration", "prototext", "freeotp", "authpro"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := Lookup(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := f.Encode(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := Decode("", "-", data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := q.OtpParameters[0]
+			if got.Name != want.Name || got.SecretString() != want.SecretString() {
+				t.Errorf("got %v; want %v", got.URL(), want.URL())
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	Register("freeotp", FormatFuncs{
+		DecodeFunc: UnmarshalFreeOTP,
+		EncodeFunc: (*Payload).FreeOTP,
+		DetectFunc: func(data []byte) bool {
+			return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) &&
+				bytes.Contains(data, []byte(`"tokenOrder"`))
+		},
+	})
+}
+
+type freeOTPToken struct {
+	Algo      string `json:"algo"`
+	Counter   uint64 `json:"counter"`
+	Digits    int    `json:"digits"`
+	IssuerExt string `json:"issuerExt"`
+	IssuerInt string `json:"issuerInt,omitempty"`
+	Label     string `json:"label"`
+	Period    int    `json:"period"`
+	Secret    []int8 `json:"secret"` // java signed bytes
+	Type      string `json:"type"`
+}
+
+type freeOTPBackup struct {
+	TokenOrder []string       `json:"tokenOrder"`
+	Tokens     []freeOTPToken `json:"tokens"`
+}
+
+// UnmarshalFreeOTP reads FreeOTP+ JSON export
+func UnmarshalFreeOTP(data []byte) (*Payload, error) {
+	var b freeOTPBackup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	ops := make([]*Payload_OtpParameters, len(b.Tokens))
+	for i, t := range b.Tokens {
+		if err := checkPeriod(t.Period); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Label, err)
+		}
+		op := &Payload_OtpParameters{
+			Secret:  make([]byte, len(t.Secret)),
+			Name:    label(t.IssuerExt, t.Label),
+			Issuer:  t.IssuerExt,
+			Counter: t.Counter,
+		}
+		for j, v := range t.Secret {
+			op.Secret[j] = byte(v)
+		}
+		var err error
+		if op.Algorithm, err = ParseAlgorithm(t.Algo); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Label, err)
+		}
+		if op.Digits, err = ParseDigits(t.Digits); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Label, err)
+		}
+		switch strings.ToUpper(t.Type) {
+		case "HOTP":
+			op.Type = Payload_OtpParameters_OTP_TYPE_HOTP
+		case "TOTP":
+			op.Type = Payload_OtpParameters_OTP_TYPE_TOTP
+		default:
+			return nil, fmt.Errorf("%s: type %s: %w", t.Label, t.Type, ErrUnsupported)
+		}
+		ops[i] = op
+	}
+	return NewPayload(ops), nil
+}
+
+// FreeOTP returns FreeOTP+ JSON export
+func (p *Payload) FreeOTP() ([]byte, error) {
+	b := freeOTPBackup{
+		TokenOrder: make([]string, len(p.OtpParameters)),
+		Tokens:     make([]freeOTPToken, len(p.OtpParameters)),
+	}
+	for i, op := range p.OtpParameters {
+		t := freeOTPToken{
+			Algo:      op.Algorithm.Name(),
+			Digits:    op.Digits.Count(),
+			IssuerExt: op.Issuer,
+			IssuerInt: op.Issuer,
+			Label:     op.Account(),
+			Period:    int(period.Seconds()),
+			Secret:    make([]int8, len(op.Secret)),
+			Type:      strings.ToUpper(op.Type.Name()),
+		}
+		if op.Type == Payload_OtpParameters_OTP_TYPE_HOTP {
+			t.Counter = op.Counter
+		}
+		for j, v := range op.Secret {
+			t.Secret[j] = int8(v)
+		}
+		b.TokenOrder[i] = label(t.IssuerExt, t.Label)
+		b.Tokens[i] = t
+	}
+	return json.Marshal(b)
+}
@@ -0,0 +1,25 @@
+package migration
+
+import "testing"
+
+func TestFreeOTP(t *testing.T) {
+	const testData = `{"tokenOrder":["Example:alice@google.com"],"tokens":[{"algo":"SHA1","counter":0,"digits":6,"issuerExt":"Example","issuerInt":"Example","label":"alice@google.com","period":30,"secret":[72,101,108,108,111,33,-34,-83,-66,-17],"type":"TOTP"}]}`
+	const want = "otpauth://totp/Example:alice@google.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=JBSWY3DPEHPK3PXP"
+	p, err := UnmarshalFreeOTP([]byte(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.OtpParameters) != 1 {
+		t.Fatalf("got length %v, want 1", len(p.OtpParameters))
+	}
+	if got := p.OtpParameters[0].URL().String(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	b, err := p.FreeOTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != testData {
+		t.Errorf("got %s, want %s", b, testData)
+	}
+}
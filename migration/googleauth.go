@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("google-authenticator", FormatFuncs{
+		DecodeFunc: func(data []byte) (*Payload, error) {
+			op, _, err := UnmarshalGoogleAuthenticator(data)
+			if err != nil {
+				return nil, err
+			}
+			return NewPayload([]*Payload_OtpParameters{op}), nil
+		},
+		EncodeFunc: func(p *Payload) ([]byte, error) {
+			if len(p.OtpParameters) != 1 {
+				return nil, fmt.Errorf("%d accounts: want exactly one", len(p.OtpParameters))
+			}
+			// payload carries no pam options, see GoogleAuthenticator for them
+			return p.OtpParameters[0].GoogleAuthenticator(nil)
+		},
+		DetectFunc: func(data []byte) bool {
+			secret, rest, _ := bytes.Cut(data, []byte("\n"))
+			_, err := decodeSecret(string(secret))
+			return err == nil && bytes.HasPrefix(rest, []byte(`" `))
+		},
+	}, ".google_authenticator")
+}
+
+// GoogleAuthenticatorOptions of pam_google_authenticator
+type GoogleAuthenticatorOptions struct {
+	WindowSize    int   // WINDOW_SIZE, 0 to omit
+	RateLimit     int   // RATE_LIMIT attempts, 0 to omit
+	RateInterval  int   // RATE_LIMIT interval in seconds
+	DisallowReuse bool  // DISALLOW_REUSE
+	ScratchCodes  []int // emergency scratch codes
+}
+
+// ScratchCodes generates n fresh 8-digit emergency scratch codes
+func ScratchCodes(n int) ([]int, error) {
+	codes := make([]int, n)
+	for i := range codes {
+		v, err := rand.Int(rand.Reader, big.NewInt(90000000))
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = 10000000 + int(v.Int64())
+	}
+	return codes, nil
+}
+
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.TrimRight(strings.TrimSpace(s), "="))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+}
+
+// UnmarshalGoogleAuthenticator reads ~/.google_authenticator file
+func UnmarshalGoogleAuthenticator(data []byte) (*Payload_OtpParameters, *GoogleAuthenticatorOptions, error) {
+	op := &Payload_OtpParameters{
+		Algorithm: Payload_OtpParameters_ALGORITHM_SHA1,
+		Digits:    Payload_OtpParameters_DIGIT_COUNT_SIX,
+		Type:      Payload_OtpParameters_OTP_TYPE_TOTP,
+	}
+	opt := &GoogleAuthenticatorOptions{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	if !s.Scan() {
+		return nil, nil, errors.New("missing secret")
+	}
+	secret, err := decodeSecret(s.Text())
+	if err != nil {
+		return nil, nil, fmt.Errorf("secret: %w", err)
+	}
+	op.Secret = secret
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, `"`) {
+			code, err := strconv.Atoi(line)
+			if err != nil {
+				return nil, nil, fmt.Errorf("scratch code: %w", err)
+			}
+			opt.ScratchCodes = append(opt.ScratchCodes, code)
+			continue
+		}
+		f := strings.Fields(strings.TrimPrefix(line, `"`))
+		if len(f) == 0 {
+			continue
+		}
+		args := make([]int, len(f)-1)
+		for i := range args {
+			if args[i], err = strconv.Atoi(f[i+1]); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", f[0], err)
+			}
+		}
+		arg := func(i int) int {
+			if i < len(args) {
+				return args[i]
+			}
+			return 0
+		}
+		switch f[0] {
+		case "TOTP_AUTH":
+			op.Type = Payload_OtpParameters_OTP_TYPE_TOTP
+		case "HOTP_COUNTER":
+			op.Type = Payload_OtpParameters_OTP_TYPE_HOTP
+			// pam counter is the next one to accept, ours is pre-incremented
+			if c := arg(0); c > 0 {
+				op.Counter = uint64(c - 1)
+			}
+		case "WINDOW_SIZE":
+			opt.WindowSize = arg(0)
+		case "RATE_LIMIT":
+			// trailing values are timestamps of recent attempts
+			opt.RateLimit, opt.RateInterval = arg(0), arg(1)
+		case "DISALLOW_REUSE":
+			// trailing values are timestamps of used codes
+			opt.DisallowReuse = true
+		case "STEP_SIZE":
+			if err := checkPeriod(arg(0)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+	return op, opt, nil
+}
+
+// GoogleAuthenticator generates ~/.google_authenticator file content
+func (op *Payload_OtpParameters) GoogleAuthenticator(opt *GoogleAuthenticatorOptions) ([]byte, error) {
+	if op.Algorithm.Name() != "SHA1" {
+		return nil, fmt.Errorf("algorithm %s: %w", op.Algorithm.Name(), ErrUnsupported)
+	}
+	if op.Digits.Count() != 6 {
+		return nil, fmt.Errorf("digits %d: %w", op.Digits.Count(), ErrUnsupported)
+	}
+	if opt == nil {
+		opt = &GoogleAuthenticatorOptions{}
+	}
+	var b bytes.Buffer
+	fmt.Fprintln(&b, op.SecretString())
+	if opt.RateLimit > 0 {
+		fmt.Fprintf(&b, "\" RATE_LIMIT %d %d\n", opt.RateLimit, opt.RateInterval)
+	}
+	if opt.WindowSize > 0 {
+		fmt.Fprintf(&b, "\" WINDOW_SIZE %d\n", opt.WindowSize)
+	}
+	if opt.DisallowReuse {
+		fmt.Fprintln(&b, `" DISALLOW_REUSE`)
+	}
+	switch op.Type.Name() {
+	case "hotp":
+		fmt.Fprintf(&b, "\" HOTP_COUNTER %d\n", op.Counter+1)
+	default:
+		fmt.Fprintln(&b, `" TOTP_AUTH`)
+	}
+	for _, code := range opt.ScratchCodes {
+		fmt.Fprintf(&b, "%08d\n", code)
+	}
+	return b.Bytes(), nil
+}
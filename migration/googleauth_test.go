@@ -0,0 +1,95 @@
+package migration
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGoogleAuthenticator(t *testing.T) {
+	const testData = `JBSWY3DPEHPK3PXP
+" RATE_LIMIT 3 30 1700000000
+" WINDOW_SIZE 17
+" DISALLOW_REUSE 56666666
+" TOTP_AUTH
+12345678
+87654321
+`
+	op, opt, err := UnmarshalGoogleAuthenticator([]byte(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.SecretString() != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("got secret %v", op.SecretString())
+	}
+	if op.Type != Payload_OtpParameters_OTP_TYPE_TOTP {
+		t.Errorf("got type %v", op.Type)
+	}
+	if opt.WindowSize != 17 || opt.RateLimit != 3 || opt.RateInterval != 30 || !opt.DisallowReuse {
+		t.Errorf("got options %+v", opt)
+	}
+	if len(opt.ScratchCodes) != 2 {
+		t.Errorf("got scratch codes %v", opt.ScratchCodes)
+	}
+	const want = `JBSWY3DPEHPK3PXP
+" RATE_LIMIT 3 30
+" WINDOW_SIZE 17
+" DISALLOW_REUSE
+" TOTP_AUTH
+12345678
+87654321
+`
+	got, err := op.GoogleAuthenticator(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got %v; want %v", string(got), want)
+	}
+}
+
+func TestGoogleAuthenticatorHOTP(t *testing.T) {
+	const testData = "JBSWY3DPEHPK3PXP\n\" HOTP_COUNTER 5\n"
+	op, opt, err := UnmarshalGoogleAuthenticator([]byte(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Type != Payload_OtpParameters_OTP_TYPE_HOTP || op.Counter != 4 {
+		t.Errorf("got type %v counter %v", op.Type, op.Counter)
+	}
+	got, err := op.GoogleAuthenticator(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte(testData)) {
+		t.Errorf("got %v; want %v", string(got), testData)
+	}
+}
+
+func TestGoogleAuthenticatorFormat(t *testing.T) {
+	f, err := Lookup("google-authenticator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPayload([]*Payload_OtpParameters{{Secret: []byte("Hello!\xde\xad\xbe\xef"), Type: Payload_OtpParameters_OTP_TYPE_TOTP}})
+	got, err := f.Encode(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no options beyond what payload carries
+	const want = "JBSWY3DPEHPK3PXP\n\" TOTP_AUTH\n"
+	if string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestScratchCodes(t *testing.T) {
+	codes, err := ScratchCodes(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range codes {
+		if code < 10000000 || code > 99999999 {
+			t.Errorf("got %v", code)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrConflict accounts match but differ
+var ErrConflict = errors.New("conflict")
+
+// Resolution of merge conflicts
+type Resolution int
+
+const (
+	KeepFirst Resolution = iota // keep account seen first
+	KeepLast                    // replace by account seen last
+	Fail                        // abort merge
+)
+
+var resolutionNames = []string{
+	KeepFirst: "first",
+	KeepLast:  "last",
+	Fail:      "fail",
+}
+
+func (r Resolution) String() string {
+	return resolutionNames[r]
+}
+
+// ParseResolution returns resolution by its name
+func ParseResolution(name string) (Resolution, error) {
+	for x, v := range resolutionNames {
+		if v == name {
+			return Resolution(x), nil
+		}
+	}
+	return 0, fmt.Errorf("resolution %s: %w", name, ErrUnknown)
+}
+
+// Changes lists fields differing between a and b, unique id is ignored
+func Changes(a, b *Payload_OtpParameters) []string {
+	var fields []string
+	if !bytes.Equal(a.Secret, b.Secret) {
+		fields = append(fields, "secret")
+	}
+	if a.Name != b.Name {
+		fields = append(fields, "name")
+	}
+	if a.Issuer != b.Issuer {
+		fields = append(fields, "issuer")
+	}
+	if a.Algorithm.Name() != b.Algorithm.Name() {
+		fields = append(fields, "algorithm")
+	}
+	if a.Digits.Count() != b.Digits.Count() {
+		fields = append(fields, "digits")
+	}
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if a.Counter != b.Counter {
+		fields = append(fields, "counter")
+	}
+	return fields
+}
+
+// Conflict of matching accounts with differing fields
+type Conflict struct {
+	First, Last *Payload_OtpParameters
+	Fields      []string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s differ", c.First.Name, strings.Join(c.Fields, ", "))
+}
+
+// matchKeys identify account by unique id, secret and label, most specific first
+func matchKeys(op *Payload_OtpParameters) []string {
+	keys := []string{
+		"uuid:" + op.UUID().String(),
+		"label:" + op.Issuer + "\x00" + op.Name,
+	}
+	if op.UniqueId != "" {
+		keys = append([]string{"id:" + op.UniqueId}, keys...)
+	}
+	return keys
+}
+
+// Merge payloads into single payload without duplicates. Accounts match by
+// unique id, by secret or by issuer and name. Matching accounts differing in
+// any field are reported as conflicts and resolved by res.
+func Merge(res Resolution, ps ...*Payload) (*Payload, []Conflict, error) {
+	var (
+		merged    []*Payload_OtpParameters
+		conflicts []Conflict
+		index     = make(map[string]int)
+	)
+	add := func(i int, op *Payload_OtpParameters) {
+		for _, key := range matchKeys(op) {
+			if _, ok := index[key]; !ok {
+				index[key] = i
+			}
+		}
+	}
+	for _, p := range ps {
+		for _, op := range p.OtpParameters {
+			i, found := -1, false
+			for _, key := range matchKeys(op) {
+				if i, found = index[key]; found {
+					break
+				}
+			}
+			if !found {
+				add(len(merged), op)
+				merged = append(merged, op)
+				continue
+			}
+			fields := Changes(merged[i], op)
+			if len(fields) == 0 {
+				continue
+			}
+			c := Conflict{First: merged[i], Last: op, Fields: fields}
+			conflicts = append(conflicts, c)
+			switch res {
+			case Fail:
+				return nil, conflicts, fmt.Errorf("%v: %w", c, ErrConflict)
+			case KeepLast:
+				merged[i] = op
+				add(i, op)
+			}
+		}
+	}
+	return NewPayload(merged), conflicts, nil
+}
@@ -0,0 +1,64 @@
+package migration
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte{1}, Name: "GitHub:alice", Issuer: "GitHub", UniqueId: "id1"},
+		{Secret: []byte{2}, Name: "bob", Type: Payload_OtpParameters_OTP_TYPE_HOTP, Counter: 3},
+	})
+	b := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte{1}, Name: "GitHub:alice", Issuer: "GitHub"},
+		{Secret: []byte{2}, Name: "bob", Type: Payload_OtpParameters_OTP_TYPE_HOTP, Counter: 5},
+		{Secret: []byte{3}, Name: "carol"},
+	})
+	testCases := []struct {
+		res     Resolution
+		counter uint64
+		err     error
+	}{
+		{res: KeepFirst, counter: 3},
+		{res: KeepLast, counter: 5},
+		{res: Fail, err: ErrConflict},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.res.String(), func(t *testing.T) {
+			p, conflicts, err := Merge(tc.res, a, b)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v; want %v", err, tc.err)
+			}
+			if len(conflicts) != 1 || !slices.Equal(conflicts[0].Fields, []string{"counter"}) {
+				t.Errorf("got conflicts %v", conflicts)
+			}
+			if err != nil {
+				return
+			}
+			if len(p.OtpParameters) != 3 {
+				t.Fatalf("got %d accounts; want 3", len(p.OtpParameters))
+			}
+			if got := p.OtpParameters[1].Counter; got != tc.counter {
+				t.Errorf("got counter %v; want %v", got, tc.counter)
+			}
+		})
+	}
+}
+
+func TestMergeLabel(t *testing.T) {
+	// same label with new secret, e.g. after reset of 2FA
+	a := NewPayload([]*Payload_OtpParameters{{Secret: []byte{1}, Name: "alice"}})
+	b := NewPayload([]*Payload_OtpParameters{{Secret: []byte{2}, Name: "alice"}})
+	p, conflicts, err := Merge(KeepLast, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.OtpParameters) != 1 || p.OtpParameters[0].Secret[0] != 2 {
+		t.Errorf("got %v; want second secret", p.OtpParameters)
+	}
+	if len(conflicts) != 1 || conflicts[0].Fields[0] != "secret" {
+		t.Errorf("got conflicts %v", conflicts)
+	}
+}
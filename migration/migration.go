@@ -5,7 +5,9 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"fmt"
 	"hash"
+	"strings"
 )
 
 //go:generate protoc --go_out=. --go_opt=paths=source_relative migration.proto
@@ -34,6 +36,16 @@ func (x Payload_OtpParameters_Algorithm) Name() string {
 	return algorithmNames[x]
 }
 
+// ParseAlgorithm returns algorithm by its name
+func ParseAlgorithm(name string) (Payload_OtpParameters_Algorithm, error) {
+	for x, v := range algorithmNames {
+		if x != 0 && strings.EqualFold(v, name) {
+			return Payload_OtpParameters_Algorithm(x), nil
+		}
+	}
+	return 0, fmt.Errorf("algorithm %s: %w", name, ErrUnsupported)
+}
+
 var digitCount = []int{
 	Payload_OtpParameters_DIGIT_COUNT_UNSPECIFIED: 6,
 	Payload_OtpParameters_DIGIT_COUNT_SIX:         6,
@@ -44,6 +56,16 @@ func (x Payload_OtpParameters_DigitCount) Count() int {
 	return digitCount[x]
 }
 
+// ParseDigits returns digit count by its value
+func ParseDigits(n int) (Payload_OtpParameters_DigitCount, error) {
+	for x, v := range digitCount {
+		if x != 0 && v == n {
+			return Payload_OtpParameters_DigitCount(x), nil
+		}
+	}
+	return 0, fmt.Errorf("digits %d: %w", n, ErrUnsupported)
+}
+
 var otpTypeFunc = []func(*Payload_OtpParameters) uint64{
 	Payload_OtpParameters_OTP_TYPE_UNSPECIFIED: totp,
 	Payload_OtpParameters_OTP_TYPE_HOTP:        hotp,
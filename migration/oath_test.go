@@ -0,0 +1,65 @@
+package migration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOath(t *testing.T) {
+	// fake time
+	now = func() time.Time { return time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC) }
+	const testData = `# type	user	pin	secret
+HOTP/T30	alice	-	48656c6c6f21deadbeef
+HOTP/E/8	bob	1234	3132333435363738393031323334353637383930	2	755224	2009-11-10T23:00:00L
+`
+	entries, err := UnmarshalOath([]byte(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got length %v, want 2", len(entries))
+	}
+	alice, bob := entries[0].Params, entries[1].Params
+	if res := alice.Evaluate(); res != 528064 {
+		t.Errorf("got %v", res)
+	}
+	if bob.Type != Payload_OtpParameters_OTP_TYPE_HOTP || bob.Counter != 1 || bob.Digits.Count() != 8 {
+		t.Errorf("got type %v counter %v digits %v", bob.Type, bob.Counter, bob.Digits.Count())
+	}
+	if entries[1].LastOTP != "755224" || entries[1].LastUsed.IsZero() {
+		t.Errorf("got last otp %v used %v", entries[1].LastOTP, entries[1].LastUsed)
+	}
+	const want = `HOTP/T30	alice	-	48656c6c6f21deadbeef	0
+HOTP/E/8	bob	1234	3132333435363738393031323334353637383930	2	755224	2009-11-10T23:00:00L
+`
+	got, err := MarshalOath(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got %v; want %v", string(got), want)
+	}
+}
+
+func TestOathEntries(t *testing.T) {
+	p := &Payload{OtpParameters: []*Payload_OtpParameters{
+		{Name: "Example:alice", Issuer: "Example", Secret: []byte("Hello!"), Type: Payload_OtpParameters_OTP_TYPE_TOTP},
+	}}
+	entries := p.OathEntries()
+	if entries[0].User != "alice" {
+		t.Errorf("got user %v; want alice", entries[0].User)
+	}
+	// fresh counter 0 can't be kept, it is exported as next one
+	fresh, err := UnmarshalOath([]byte("HOTP\tbob\t-\t48656c6c6f21\t0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := MarshalOath(append(entries, fresh...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "HOTP/T30\talice\t-\t48656c6c6f21\t0\nHOTP/E\tbob\t-\t48656c6c6f21\t1\n"
+	if string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
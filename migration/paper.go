@@ -0,0 +1,211 @@
+package migration
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Checksum of serialized accounts in order, to verify printed backups.
+// Batch fields are not covered, so checksum survives splitting into batches.
+func (p *Payload) Checksum() (string, error) {
+	h := sha256.New()
+	for _, op := range p.OtpParameters {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(op)
+		if err != nil {
+			return "", err
+		}
+		// length prefix keeps account boundaries unambiguous
+		h.Write(protowire.AppendVarint(nil, uint64(len(data))))
+		h.Write(data)
+	}
+	sum := hex.EncodeToString(h.Sum(nil)[:8])
+	return sum[0:4] + "-" + sum[4:8] + "-" + sum[8:12] + "-" + sum[12:16], nil
+}
+
+// A4 page geometry in points
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	blockSize  = 160 // height of single account
+)
+
+// pdf is minimal writer of pages with text in standard fonts and filled rectangles
+type pdf struct {
+	pages []*bytes.Buffer
+}
+
+func (d *pdf) newPage() *bytes.Buffer {
+	b := new(bytes.Buffer)
+	d.pages = append(d.pages, b)
+	return b
+}
+
+// winAnsi encodes string as WinAnsi text, runes beyond Latin-1 become '?'
+func winAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > 0xff || (r >= 0x7f && r < 0xa0):
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+// pdfText in font F1 (Helvetica), F2 (Helvetica-Bold) or F3 (Courier)
+func pdfText(w *bytes.Buffer, font string, size, x, y float64, s string) {
+	fmt.Fprintf(w, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, y, winAnsi(s))
+}
+
+// pdfQR draws QR code of u with lower left corner at x, y
+func pdfQR(w *bytes.Buffer, x, y, size float64, u *url.URL) error {
+	q, err := qrcode.New(u.String(), qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	bitmap := q.Bitmap()
+	m := size / float64(len(bitmap))
+	for row := range bitmap {
+		// join dark modules of a row into runs
+		for col := 0; col < len(bitmap[row]); col++ {
+			if !bitmap[row][col] {
+				continue
+			}
+			start := col
+			for col < len(bitmap[row]) && bitmap[row][col] {
+				col++
+			}
+			fmt.Fprintf(w, "%.2f %.2f %.2f %.2f re\n",
+				x+float64(start)*m, y+size-float64(row+1)*m, float64(col-start)*m, m)
+		}
+	}
+	w.WriteString("f\n")
+	return nil
+}
+
+// bytes serializes document with compressed content streams
+func (d *pdf) bytes() ([]byte, error) {
+	var objs [][]byte
+	add := func(s string) int {
+		objs = append(objs, []byte(s))
+		return len(objs)
+	}
+	add("<< /Type /Catalog /Pages 2 0 R >>")
+	add("") // pages, filled in below
+	fonts := add("<< /F1 4 0 R /F2 5 0 R /F3 6 0 R >>")
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	var kids []string
+	for _, page := range d.pages {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		content := add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+		n := add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font %d 0 R >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fonts, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", n))
+	}
+	objs[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return b.Bytes(), nil
+}
+
+// Parameters describes type, algorithm, digits and period or counter
+func (op *Payload_OtpParameters) Parameters() string {
+	s := fmt.Sprintf("%s, %s, %d digits", strings.ToUpper(op.Type.Name()), op.Algorithm.Name(), op.Digits.Count())
+	if op.Period() == 0 {
+		return s + fmt.Sprintf(", counter %d", op.Counter)
+	}
+	return s + fmt.Sprintf(", period %d s", op.Period())
+}
+
+// Paper renders printable PDF backup with QR code, secret and parameters of
+// every account followed by migration batches of batch accounts each
+func (p *Payload) Paper(date time.Time, batch int) ([]byte, error) {
+	sum, err := p.Checksum()
+	if err != nil {
+		return nil, err
+	}
+	var d pdf
+	var w *bytes.Buffer
+	y := 0.0
+	for _, op := range p.OtpParameters {
+		if w == nil || y-blockSize < margin+20 {
+			w = d.newPage()
+			y = pageHeight - margin - 30
+		}
+		y -= blockSize
+		if err := pdfQR(w, margin, y+10, blockSize-20, op.URL()); err != nil {
+			return nil, err
+		}
+		x := float64(margin + blockSize)
+		pdfText(w, "F2", 14, x, y+blockSize-30, op.Issuer)
+		pdfText(w, "F1", 11, x, y+blockSize-48, op.Name)
+		tuples := op.SecretTuples()
+		line := 0
+		for i := 0; i < len(tuples); i += 8 {
+			pdfText(w, "F3", 11, x, y+blockSize-72-float64(line)*14, strings.Join(tuples[i:min(i+8, len(tuples))], " "))
+			line++
+		}
+		pdfText(w, "F1", 10, x, y+blockSize-80-float64(line)*14, op.Parameters())
+	}
+	batches := p.Batches(batch)
+	for i, b := range batches {
+		data, err := Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		if i%2 == 0 {
+			w = d.newPage()
+			y = pageHeight - margin - 30
+		}
+		const size = 320
+		y -= size + 30
+		pdfText(w, "F2", 12, margin, y+size+10, fmt.Sprintf("Migration batch %d of %d", i+1, len(batches)))
+		if err := pdfQR(w, (pageWidth-size)/2, y, size, URL(data)); err != nil {
+			return nil, err
+		}
+	}
+	for i, w := range d.pages {
+		pdfText(w, "F2", 12, margin, pageHeight-margin, "OTPAuth paper backup")
+		pdfText(w, "F1", 10, pageWidth-margin-50, pageHeight-margin, date.Format(time.DateOnly))
+		pdfText(w, "F1", 9, margin, margin-10, fmt.Sprintf("%d accounts, checksum %s", len(p.OtpParameters), sum))
+		pdfText(w, "F1", 9, pageWidth-margin-60, margin-10, fmt.Sprintf("page %d of %d", i+1, len(d.pages)))
+	}
+	return d.bytes()
+}
@@ -0,0 +1,82 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/base32"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaper(t *testing.T) {
+	ops := make([]*Payload_OtpParameters, 7)
+	for i := range ops {
+		ops[i] = &Payload_OtpParameters{
+			Secret: []byte(fmt.Sprintf("secret%02d", i)),
+			Name:   fmt.Sprintf("Example:user%d (ü)", i),
+			Issuer: "Example",
+			Type:   Payload_OtpParameters_OTP_TYPE_TOTP,
+		}
+	}
+	p := NewPayload(ops)
+	data, err := p.Paper(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	// 2 pages of accounts, 1 page of 2 batches
+	if !bytes.Contains(data, []byte("/Count 3 >>")) {
+		t.Error("want 3 pages")
+	}
+	// every xref entry points to its object
+	xref := bytes.LastIndex(data, []byte("xref\n"))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("xref %d points to %q", i+1, data[off:off+10])
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	p := NewPayload([]*Payload_OtpParameters{{Secret: []byte{1}, Name: "alice"}})
+	a, err := p.Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{4}(-[0-9a-f]{4}){3}$`).MatchString(a) {
+		t.Errorf("got %q", a)
+	}
+	// batch fields are not covered
+	q := NewPayload(p.OtpParameters)
+	q.BatchId, q.BatchSize = 42, 3
+	if b, _ := q.Checksum(); a != b {
+		t.Errorf("got %v; want %v", b, a)
+	}
+	p.OtpParameters[0].Name = "bob"
+	if b, _ := p.Checksum(); a == b {
+		t.Errorf("checksum %v did not change", a)
+	}
+}
+
+func TestSecretTuples(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	op := &Payload_OtpParameters{Secret: secret}
+	tuples := op.SecretTuples()
+	if last := tuples[len(tuples)-1]; len(last) != 2 {
+		t.Errorf("last tuple %q; want 2 characters", last)
+	}
+	got, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.Join(tuples, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("got %x; want %x", got, secret)
+	}
+}
@@ -0,0 +1,181 @@
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("paperkey", FormatFuncs{
+		DecodeFunc: UnmarshalPaperKey,
+		EncodeFunc: MarshalPaperKey,
+		DetectFunc: func(data []byte) bool {
+			return bytes.HasPrefix(bytes.TrimSpace(data), []byte(paperKeyHeader))
+		},
+	}, ".paperkey")
+}
+
+const (
+	paperKeyHeader = "# otpauth paper key"
+	paperKeyWidth  = 15 // bytes per line, 24 base32 characters
+)
+
+var paperKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// crc24 of OpenPGP, RFC 4880 section 6.1
+func crc24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for range 8 {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
+
+// lineCRC covers line number, so swapped lines are detected as well
+func lineCRC(n int, data []byte) uint32 {
+	return crc24(append([]byte{byte(n >> 8), byte(n)}, data...))
+}
+
+// MarshalPaperKey encodes payload as numbered base32 lines with CRC-24 each,
+// followed by length and CRC-24 of whole document
+func MarshalPaperKey(p *Payload) ([]byte, error) {
+	data, err := Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	lines := (len(data) + paperKeyWidth - 1) / paperKeyWidth
+	fmt.Fprintf(&b, "%s, %d accounts, %d lines\n", paperKeyHeader, len(p.OtpParameters), lines)
+	for n := 1; n <= lines; n++ {
+		chunk := data[(n-1)*paperKeyWidth : min(n*paperKeyWidth, len(data))]
+		s := paperKeyEncoding.EncodeToString(chunk)
+		var groups []string
+		for i := 0; i < len(s); i += 4 {
+			groups = append(groups, s[i:min(i+4, len(s))])
+		}
+		fmt.Fprintf(&b, "%3d: %-29s %06X\n", n, strings.Join(groups, " "), lineCRC(n, chunk))
+	}
+	fmt.Fprintf(&b, "end: %d %06X\n", len(data), crc24(data))
+	return b.Bytes(), nil
+}
+
+// LineError pinpoints damaged or mistyped line of paper key
+type LineError struct {
+	Line   int
+	Reason string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// typos maps characters outside of base32 alphabet to likely meant ones
+var typos = strings.NewReplacer("0", "O", "1", "I", "8", "B", " ", "", "\t", "")
+
+// UnmarshalPaperKey decodes typed paper key, lines may be in any order and
+// blank or comment lines are skipped. Every damaged line is reported as
+// LineError, joined into single error.
+func UnmarshalPaperKey(data []byte) (*Payload, error) {
+	var (
+		errs   []error
+		chunks = make(map[int][]byte)
+		size   = -1
+		sum    uint32
+	)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		label, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			errs = append(errs, fmt.Errorf("%q: missing line number", line))
+			continue
+		}
+		fields := strings.Fields(rest)
+		if strings.TrimSpace(label) == "end" {
+			if len(fields) != 2 {
+				errs = append(errs, errors.New("end: want length and checksum"))
+				continue
+			}
+			n, err1 := strconv.Atoi(fields[0])
+			c, err2 := strconv.ParseUint(fields[1], 16, 24)
+			if err1 != nil || err2 != nil {
+				errs = append(errs, errors.New("end: malformed length or checksum"))
+				continue
+			}
+			size, sum = n, uint32(c)
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(label))
+		if err != nil || n < 1 {
+			errs = append(errs, fmt.Errorf("%q: malformed line number", label))
+			continue
+		}
+		if len(fields) < 2 {
+			errs = append(errs, &LineError{Line: n, Reason: "missing checksum"})
+			continue
+		}
+		crc, err := strconv.ParseUint(fields[len(fields)-1], 16, 24)
+		if err != nil {
+			errs = append(errs, &LineError{Line: n, Reason: "malformed checksum"})
+			continue
+		}
+		text := typos.Replace(strings.ToUpper(strings.Join(fields[:len(fields)-1], "")))
+		chunk, err := paperKeyEncoding.DecodeString(text)
+		if err != nil {
+			errs = append(errs, &LineError{Line: n, Reason: "invalid character: " + err.Error()})
+			continue
+		}
+		if lineCRC(n, chunk) != uint32(crc) {
+			errs = append(errs, &LineError{Line: n, Reason: "checksum mismatch"})
+			continue
+		}
+		chunks[n] = chunk
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		errs = append(errs, errors.New("end line missing"))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	lines := (size + paperKeyWidth - 1) / paperKeyWidth
+	var missing []error
+	for n := 1; n <= lines; n++ {
+		if _, ok := chunks[n]; !ok {
+			missing = append(missing, &LineError{Line: n, Reason: "missing"})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.Join(missing...)
+	}
+	numbers := make([]int, 0, len(chunks))
+	for n := range chunks {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	var buf []byte
+	for _, n := range numbers[:lines] {
+		buf = append(buf, chunks[n]...)
+	}
+	if len(buf) != size || crc24(buf) != sum {
+		return nil, errors.New("document checksum mismatch")
+	}
+	return Unmarshal(buf)
+}
@@ -0,0 +1,63 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCRC24(t *testing.T) {
+	// check value of CRC-24/OPENPGP
+	if got := crc24([]byte("123456789")); got != 0x21cf02 {
+		t.Errorf("got %06x; want 21cf02", got)
+	}
+}
+
+func TestPaperKey(t *testing.T) {
+	p := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte("Hello!\xde\xad\xbe\xef"), Name: "Example:alice@google.com", Issuer: "Example", Type: Payload_OtpParameters_OTP_TYPE_TOTP},
+	})
+	data, err := MarshalPaperKey(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := Detect("", data); name != "paperkey" {
+		t.Errorf("detected as %q", name)
+	}
+	// lowercase, reversed line order and typical typos are accepted
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i, j := 1, len(lines)-2; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	typed := strings.ReplaceAll(strings.ToLower(strings.Join(lines, "\n")), "o", "0")
+	q, err := UnmarshalPaperKey([]byte(typed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(q.OtpParameters[0].Secret, p.OtpParameters[0].Secret) {
+		t.Errorf("got %v", q)
+	}
+}
+
+func TestPaperKeyTypo(t *testing.T) {
+	p := NewPayload([]*Payload_OtpParameters{{Secret: []byte("Hello!\xde\xad\xbe\xef"), Name: "Example:alice@google.com"}})
+	data, _ := MarshalPaperKey(p)
+	lines := strings.Split(string(data), "\n")
+	// swap two characters in line 2
+	typo := slices.Clone(lines)
+	b := []byte(typo[2])
+	b[5], b[6] = b[6], b[5]
+	typo[2] = string(b)
+	_, err := UnmarshalPaperKey([]byte(strings.Join(typo, "\n")))
+	var le *LineError
+	if !errors.As(err, &le) || le.Line != 2 {
+		t.Errorf("got error %v; want line 2", err)
+	}
+	// drop line 1
+	_, err = UnmarshalPaperKey([]byte(strings.Join(append(lines[:1:1], lines[2:]...), "\n")))
+	if !errors.As(err, &le) || le.Line != 1 || le.Reason != "missing" {
+		t.Errorf("got error %v; want line 1 missing", err)
+	}
+}
@@ -0,0 +1,136 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// PassEntry returns pass-otp compatible entry content
+func (op *Payload_OtpParameters) PassEntry() []byte {
+	return []byte(op.URL().String() + "\n")
+}
+
+func encryptAge(data []byte, recipients ...age.Recipient) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := age.Encrypt(&b, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ParseAgeRecipients parses age recipients, one per line
+func ParseAgeRecipients(r io.Reader) ([]age.Recipient, error) {
+	return age.ParseRecipients(r)
+}
+
+// ExportPass writes one pass-otp entry per account below dir, encrypted to
+// age recipients (.age) as read by passage. At least one recipient is
+// required, plain text entries are not readable by any pass variant.
+func ExportPass(dir string, p *Payload, recipients ...age.Recipient) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("pass entries without age recipients: %w", ErrUnsupported)
+	}
+	return writePass(dir, p, ".age", func(_ string, data []byte) ([]byte, error) {
+		return encryptAge(data, recipients...)
+	})
+}
+
+// ExportPassGPG writes one pass-otp entry per account below dir, encrypted
+// by gpg (.gpg) to keys of nearest .gpg-id, as pass does
+func ExportPassGPG(dir string, p *Payload) error {
+	return writePass(dir, p, ".gpg", func(fname string, data []byte) ([]byte, error) {
+		ids, err := gpgIDs(filepath.Dir(fname))
+		if err != nil {
+			return nil, err
+		}
+		return encryptGPG(data, ids...)
+	})
+}
+
+// gpgIDs reads keys from .gpg-id of dir or its closest parent
+func gpgIDs(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, ".gpg-id"))
+		if err == nil {
+			var ids []string
+			for _, line := range strings.Split(string(data), "\n") {
+				line, _, _ = strings.Cut(line, "#")
+				if line = strings.TrimSpace(line); line != "" {
+					ids = append(ids, line)
+				}
+			}
+			if len(ids) == 0 {
+				return nil, fmt.Errorf("%s: no keys", filepath.Join(dir, ".gpg-id"))
+			}
+			return ids, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errors.New("no .gpg-id found, initialize store with pass init or use age recipients")
+		}
+		dir = parent
+	}
+}
+
+func encryptGPG(data []byte, ids ...string) ([]byte, error) {
+	args := []string{"--batch", "--quiet", "--yes", "--compress-algo=none", "--no-encrypt-to", "--encrypt"}
+	for _, id := range ids {
+		args = append(args, "--recipient", id)
+	}
+	var out, stderr bytes.Buffer
+	cmd := exec.Command("gpg", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(data), &out, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("gpg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out.Bytes(), nil
+}
+
+// writePass writes entries encrypted by encrypt, which gets target file name
+func writePass(dir string, p *Payload, ext string, encrypt func(string, []byte) ([]byte, error)) error {
+	seen := make(map[string]bool)
+	for _, op := range p.OtpParameters {
+		name := op.PassName()
+		if name == "" {
+			return fmt.Errorf("account %s: empty name", op.UUID())
+		}
+		if seen[name] {
+			return fmt.Errorf("account %s: duplicate entry %s", op.UUID(), name)
+		}
+		seen[name] = true
+		fname := filepath.Join(dir, filepath.FromSlash(name)+ext)
+		data, err := encrypt(fname, op.PassEntry())
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(fname), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fname, data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
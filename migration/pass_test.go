@@ -0,0 +1,136 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestPassName(t *testing.T) {
+	testCases := []struct {
+		op   *Payload_OtpParameters
+		want string
+	}{
+		{
+			op:   &Payload_OtpParameters{Name: "Example:alice@google.com", Issuer: "Example"},
+			want: "Example/alice_google_com",
+		},
+		{
+			op:   &Payload_OtpParameters{Name: "../alice"},
+			want: "___alice",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.op.Name, func(t *testing.T) {
+			got := tc.op.PassName()
+			if got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExportPass(t *testing.T) {
+	const testData = "otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC"
+	p, err := UnmarshalURL(testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := ExportPass(dir, p, id.Recipient()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportPass(t.TempDir(), p); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("without recipients: got error %v; want %v", err, ErrUnsupported)
+	}
+	f, err := os.Open(filepath.Join(dir, "Example", "alice_google_com.age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := age.Decrypt(f, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := p.OtpParameters[0].PassEntry(); !bytes.Equal(got, want) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestGPGIDs(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "otp", "Example")
+	if err := os.MkdirAll(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gpg-id"), []byte("alice@example.com\n# comment\n\nbob@example.com # work\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := gpgIDs(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice@example.com", "bob@example.com"}; !slices.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if err := os.WriteFile(filepath.Join(sub, ".gpg-id"), []byte("carol@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = gpgIDs(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"carol@example.com"}; !slices.Equal(got, want) {
+		t.Errorf("nearest: got %v; want %v", got, want)
+	}
+}
+
+func TestExportPassGPG(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not found")
+	}
+	t.Setenv("GNUPGHOME", t.TempDir())
+	t.Cleanup(func() { exec.Command("gpgconf", "--kill", "gpg-agent").Run() })
+	gen := exec.Command("gpg", "--batch", "--quiet", "--passphrase", "", "--quick-gen-key", "otpauth@example.com", "default", "default", "never")
+	if out, err := gen.CombinedOutput(); err != nil {
+		t.Skipf("gpg key generation: %v: %s", err, out)
+	}
+	const testData = "otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC"
+	p, err := UnmarshalURL(testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := t.TempDir()
+	dir := filepath.Join(store, "otp")
+	if err := ExportPassGPG(dir, p); err == nil {
+		t.Error("without .gpg-id: got no error")
+	}
+	if err := os.WriteFile(filepath.Join(store, ".gpg-id"), []byte("otpauth@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportPassGPG(dir, p); err != nil {
+		t.Fatal(err)
+	}
+	got, err := exec.Command("gpg", "--batch", "--quiet", "--decrypt", filepath.Join(dir, "Example", "alice_google_com.gpg")).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := p.OtpParameters[0].PassEntry(); !bytes.Equal(got, want) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
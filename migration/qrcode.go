@@ -3,6 +3,7 @@ package migration
 import (
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/skip2/go-qrcode"
 )
@@ -20,3 +21,55 @@ func PNG(filename string, u *url.URL) error {
 	}
 	return os.WriteFile(filename, pic, 0600)
 }
+
+// TermMode of terminal QR code rendering
+type TermMode int
+
+const (
+	HalfBlock TermMode = iota // Unicode half blocks, two modules per character
+	ANSI                      // ANSI background colors, two characters per module
+)
+
+// Terminal renders QR code for a dark terminal, inverted for a light one
+func Terminal(u *url.URL, mode TermMode, invert bool) (string, error) {
+	q, err := qrcode.New(u.String(), qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+	bitmap := q.Bitmap()
+	// fill reports modules to be drawn in foreground color
+	fill := func(y, x int) bool {
+		return y < len(bitmap) && bitmap[y][x] == invert
+	}
+	var b strings.Builder
+	switch mode {
+	case ANSI:
+		for y := range bitmap {
+			for x := range bitmap[y] {
+				if fill(y, x) {
+					b.WriteString("\x1b[47m  ")
+				} else {
+					b.WriteString("\x1b[40m  ")
+				}
+			}
+			b.WriteString("\x1b[0m\n")
+		}
+	default:
+		for y := 0; y < len(bitmap); y += 2 {
+			for x := range bitmap[y] {
+				switch top, bottom := fill(y, x), fill(y+1, x); {
+				case top && bottom:
+					b.WriteRune('█')
+				case top:
+					b.WriteRune('▀')
+				case bottom:
+					b.WriteRune('▄')
+				default:
+					b.WriteRune(' ')
+				}
+			}
+			b.WriteRune('\n')
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,272 @@
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("shamir", FormatFuncs{
+		DecodeFunc: UnmarshalShares,
+		DetectFunc: func(data []byte) bool {
+			return bytes.HasPrefix(bytes.TrimSpace(data), []byte(sharePrefix))
+		},
+	})
+}
+
+const sharePrefix = "otpauth-share://"
+
+// ErrShares are insufficient or do not belong together
+var ErrShares = errors.New("shares mismatch")
+
+// GF(256) with AES polynomial x⁸+x⁴+x³+x+1, generator 3
+var gfExp, gfLog [256]byte
+
+func init() {
+	x := byte(1)
+	for i := range 255 {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// multiply by 3
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+255-int(gfLog[b]))%255]
+}
+
+// split secret into n shares of which any k recover it, share i is
+// evaluated at x = i+1 of random polynomial of degree k-1 per byte
+func split(secret []byte, k, n int) [][]byte {
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret))
+	}
+	coef := make([]byte, k)
+	for j, s := range secret {
+		rand.Read(coef[1:])
+		coef[0] = s
+		for i := range shares {
+			x := byte(i + 1)
+			// Horner's method
+			var y byte
+			for c := k - 1; c >= 0; c-- {
+				y = gfMul(y, x) ^ coef[c]
+			}
+			shares[i][j] = y
+		}
+	}
+	return shares
+}
+
+// combine shares by Lagrange interpolation at x = 0
+func combine(xs []byte, ys [][]byte) []byte {
+	secret := make([]byte, len(ys[0]))
+	for i, xi := range xs {
+		// basis polynomial of xi at 0
+		l := byte(1)
+		for j, xj := range xs {
+			if i != j {
+				l = gfMul(l, gfDiv(xj, xj^xi))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(l, ys[i][b])
+		}
+	}
+	return secret
+}
+
+// Share of key to payload encrypted with AES-GCM, every share carries the
+// encrypted payload, any K of N shares recover it
+type Share struct {
+	ID   uint32 // identifies shares of same split
+	K, N int
+	X    int
+	Key  []byte // share of key
+	Data []byte // nonce and sealed payload
+}
+
+// URL of share, suitable for QR code
+func (s *Share) URL() *url.URL {
+	v := make(url.Values)
+	v.Add("id", fmt.Sprintf("%08x", s.ID))
+	v.Add("k", strconv.Itoa(s.K))
+	v.Add("n", strconv.Itoa(s.N))
+	v.Add("x", strconv.Itoa(s.X))
+	v.Add("key", base64.RawURLEncoding.EncodeToString(s.Key))
+	v.Add("data", base64.RawURLEncoding.EncodeToString(s.Data))
+	return &url.URL{
+		Scheme:   "otpauth-share",
+		Host:     "offline",
+		RawQuery: v.Encode(),
+	}
+}
+
+// ParseShare parses share URL
+func ParseShare(link string) (*Share, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "otpauth-share" {
+		return nil, fmt.Errorf("scheme %s: %w", u.Scheme, ErrUnknown)
+	}
+	v := u.Query()
+	s := new(Share)
+	id, err := strconv.ParseUint(v.Get("id"), 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("id: %w", err)
+	}
+	s.ID = uint32(id)
+	for _, f := range []struct {
+		name string
+		n    *int
+	}{{"k", &s.K}, {"n", &s.N}, {"x", &s.X}} {
+		if *f.n, err = strconv.Atoi(v.Get(f.name)); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	if s.Key, err = base64.RawURLEncoding.DecodeString(v.Get("key")); err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+	if s.Data, err = base64.RawURLEncoding.DecodeString(v.Get("data")); err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+	return s, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Split encrypts payload with random key and splits key into n shares,
+// any k of them recover payload
+func (p *Payload) Split(k, n int) ([]*Share, error) {
+	if k < 1 || k > n || n > 255 {
+		return nil, fmt.Errorf("%d of %d shares: %w", k, n, ErrUnsupported)
+	}
+	data, err := Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	sealed := gcm.Seal(nonce, nonce, data, nil)
+	var id [4]byte
+	rand.Read(id[:])
+	shares := make([]*Share, n)
+	for i, key := range split(key, k, n) {
+		shares[i] = &Share{
+			ID:   binary.BigEndian.Uint32(id[:]),
+			K:    k,
+			N:    n,
+			X:    i + 1,
+			Key:  key,
+			Data: sealed,
+		}
+	}
+	return shares, nil
+}
+
+// Recover payload from at least K shares of same split
+func Recover(shares []*Share) (*Payload, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares: %w", ErrShares)
+	}
+	first := shares[0]
+	var (
+		xs   []byte
+		keys [][]byte
+		seen = make(map[int]bool)
+	)
+	for _, s := range shares {
+		if s.ID != first.ID || s.K != first.K || !bytes.Equal(s.Data, first.Data) {
+			return nil, fmt.Errorf("share %d of %08x, want %08x: %w", s.X, s.ID, first.ID, ErrShares)
+		}
+		if s.X < 1 || s.X > 255 || len(s.Key) != 32 || seen[s.X] {
+			continue
+		}
+		seen[s.X] = true
+		xs = append(xs, byte(s.X))
+		keys = append(keys, s.Key)
+		if len(xs) == first.K {
+			break
+		}
+	}
+	if len(xs) < first.K {
+		return nil, fmt.Errorf("%d of %d shares: %w", len(xs), first.K, ErrShares)
+	}
+	gcm, err := newGCM(combine(xs, keys))
+	if err != nil {
+		return nil, err
+	}
+	if len(first.Data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("data: %w", ErrShares)
+	}
+	nonce, sealed := first.Data[:gcm.NonceSize()], first.Data[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", ErrShares)
+	}
+	return Unmarshal(data)
+}
+
+// UnmarshalShares recovers payload from share URLs, one per line
+func UnmarshalShares(data []byte) (*Payload, error) {
+	var shares []*Share
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		share, err := ParseShare(line)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return Recover(shares)
+}
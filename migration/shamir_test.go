@@ -0,0 +1,64 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGF(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if got := gfDiv(gfMul(byte(a), byte(b)), byte(b)); got != byte(a) {
+				t.Fatalf("%d*%d/%d = %d", a, b, b, got)
+			}
+		}
+	}
+	// known product in AES field, FIPS-197 section 4.2
+	if got := gfMul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("got %#x; want 0xc1", got)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	p := NewPayload([]*Payload_OtpParameters{
+		{Secret: []byte("Hello!"), Name: "Example:alice", Issuer: "Example"},
+	})
+	shares, err := p.Split(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every combination of 3 out of 5 shares
+	for i := range shares {
+		for j := i + 1; j < len(shares); j++ {
+			for k := j + 1; k < len(shares); k++ {
+				var links []string
+				for _, s := range []*Share{shares[k], shares[i], shares[j]} {
+					links = append(links, s.URL().String())
+				}
+				q, err := UnmarshalShares([]byte(strings.Join(links, "\n")))
+				if err != nil {
+					t.Fatalf("shares %d %d %d: %v", i, j, k, err)
+				}
+				if !bytes.Equal(q.OtpParameters[0].Secret, []byte("Hello!")) {
+					t.Errorf("got %v", q.OtpParameters[0])
+				}
+			}
+		}
+	}
+	if _, err := Recover(shares[:2]); !errors.Is(err, ErrShares) {
+		t.Errorf("got error %v; want %v", err, ErrShares)
+	}
+	if _, err := Recover([]*Share{shares[0], shares[0], shares[1]}); !errors.Is(err, ErrShares) {
+		t.Errorf("duplicate share: got error %v; want %v", err, ErrShares)
+	}
+	other, _ := p.Split(3, 5)
+	if _, err := Recover([]*Share{shares[0], shares[1], other[2]}); !errors.Is(err, ErrShares) {
+		t.Errorf("foreign share: got error %v; want %v", err, ErrShares)
+	}
+	shares[0].Key[0] ^= 1
+	if _, err := Recover(shares[:3]); !errors.Is(err, ErrShares) {
+		t.Errorf("corrupt share: got error %v; want %v", err, ErrShares)
+	}
+}
@@ -1,9 +1,10 @@
 package migration
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/base64"
 	"errors"
-	"fmt"
 	"net/url"
 	"strings"
 
@@ -11,26 +12,50 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+func init() {
+	Register("migration", FormatFuncs{
+		DecodeFunc: Unmarshal,
+		EncodeFunc: Marshal,
+		DetectFunc: func(data []byte) bool {
+			p, err := Unmarshal(data)
+			return err == nil && len(p.OtpParameters) > 0 && len(p.OtpParameters[0].Secret) > 0
+		},
+	}, ".bin")
+	Register("link", FormatFuncs{
+		DecodeFunc: UnmarshalLinks,
+		EncodeFunc: func(p *Payload) ([]byte, error) {
+			data, err := Marshal(p)
+			if err != nil {
+				return nil, err
+			}
+			return []byte(URL(data).String() + "\n"), nil
+		},
+		DetectFunc: func(data []byte) bool {
+			return len(Links(data)) > 0
+		},
+	})
+	Register("prototext", FormatFuncs{
+		DecodeFunc: func(data []byte) (*Payload, error) {
+			var p Payload
+			if err := prototext.Unmarshal(data, &p); err != nil {
+				return nil, err
+			}
+			return &p, nil
+		},
+		EncodeFunc: func(p *Payload) ([]byte, error) {
+			return []byte(p.Pretty() + "\n"), nil
+		},
+		DetectFunc: func(data []byte) bool {
+			return bytes.HasPrefix(bytes.TrimSpace(data), []byte("otp_parameters"))
+		},
+	}, ".txtpb", ".textproto")
+}
+
 // ErrUnknown scheme or host
 var ErrUnknown = errors.New("unknown")
 
-// Data extracts data part from URL string
-func Data(link string) ([]byte, error) {
-	u, err := url.Parse(link)
-	if err != nil {
-		return nil, err
-	}
-	if u.Scheme != "otpauth-migration" {
-		return nil, fmt.Errorf("scheme %s: %w", u.Scheme, ErrUnknown)
-	}
-	if u.Host != "offline" {
-		return nil, fmt.Errorf("host %s: %w", u.Host, ErrUnknown)
-	}
-	data := u.Query().Get("data")
-	// fix spaces back to plus sign
-	data = strings.ReplaceAll(data, " ", "+")
-	return base64.StdEncoding.DecodeString(data)
-}
+// ErrUnsupported parameter or option
+var ErrUnsupported = errors.New("unsupported")
 
 // URL constructs migration URL
 func URL(data []byte) *url.URL {
@@ -52,6 +77,20 @@ func Unmarshal(data []byte) (*Payload, error) {
 	return &p, nil
 }
 
+// NewPayload wraps otp parameters into a single batch payload
+func NewPayload(ops []*Payload_OtpParameters) *Payload {
+	return &Payload{
+		OtpParameters: ops,
+		Version:       1,
+		BatchSize:     1,
+	}
+}
+
+// Marshal otpauth-migration data
+func Marshal(p *Payload) ([]byte, error) {
+	return proto.Marshal(p)
+}
+
 // UnmarshalURL decodes otpauth-migration from URL
 func UnmarshalURL(link string) (*Payload, error) {
 	data, err := Data(link)
@@ -61,6 +100,60 @@ func UnmarshalURL(link string) (*Payload, error) {
 	return Unmarshal(data)
 }
 
+const linkPrefix = "otpauth-migration://"
+
+// Links extracts migration links, one per line, ignoring surrounding
+// whitespace and scanner prefixes like zbarimg's "QR-Code:". A link wrapped
+// across lines is joined with directly following lines while it is incomplete.
+func Links(data []byte) []string {
+	var (
+		links []string
+		last  bool // previous line belongs to last link
+	)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		switch i := strings.Index(line, linkPrefix); {
+		case i >= 0:
+			links = append(links, strings.TrimSpace(line[i:]))
+			last = true
+		case last && isWrapped(line) && !complete(links[len(links)-1]):
+			// continuation of link wrapped across lines
+			links[len(links)-1] += strings.TrimSpace(line)
+		default:
+			last = false
+		}
+	}
+	return links
+}
+
+// complete reports whether link decodes into payload
+func complete(link string) bool {
+	_, err := UnmarshalURL(link)
+	return err == nil
+}
+
+// UnmarshalLinks decodes migration links, one per line, into single payload
+func UnmarshalLinks(data []byte) (*Payload, error) {
+	links := Links(data)
+	if len(links) == 0 {
+		return nil, errors.New("no migration link")
+	}
+	p, err := UnmarshalURL(links[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links[1:] {
+		q, err := UnmarshalURL(link)
+		if err != nil {
+			return nil, err
+		}
+		p.OtpParameters = append(p.OtpParameters, q.OtpParameters...)
+		p.BatchSize, p.BatchIndex = 1, 0
+	}
+	return p, nil
+}
+
 func (p *Payload) Pretty() string {
 	return prototext.Format(p)
 }
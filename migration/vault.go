@@ -0,0 +1,252 @@
+package migration
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// VaultVersion of vault schema written by this package
+const VaultVersion = 1
+
+// ErrExists account with same secret is already stored
+var ErrExists = errors.New("exists")
+
+// Account stored in vault with metadata not covered by migration payload
+type Account struct {
+	*Payload_OtpParameters
+	Tags     []string
+	Notes    string
+	Group    string
+	Order    int // manual sort order, 0 for none
+	Created  time.Time
+	Modified time.Time
+}
+
+type accountJSON struct {
+	Params   json.RawMessage `json:"params"`
+	Tags     []string        `json:"tags,omitempty"`
+	Notes    string          `json:"notes,omitempty"`
+	Group    string          `json:"group,omitempty"`
+	Order    int             `json:"order,omitempty"`
+	Created  time.Time       `json:"created"`
+	Modified time.Time       `json:"modified"`
+}
+
+// MarshalJSON encodes otp parameters as protobuf JSON
+func (a *Account) MarshalJSON() ([]byte, error) {
+	params, err := protojson.Marshal(a.Payload_OtpParameters)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(accountJSON{
+		Params:   params,
+		Tags:     a.Tags,
+		Notes:    a.Notes,
+		Group:    a.Group,
+		Order:    a.Order,
+		Created:  a.Created,
+		Modified: a.Modified,
+	})
+}
+
+// UnmarshalJSON decodes otp parameters from protobuf JSON
+func (a *Account) UnmarshalJSON(data []byte) error {
+	var v accountJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	a.Payload_OtpParameters = new(Payload_OtpParameters)
+	if err := protojson.Unmarshal(v.Params, a.Payload_OtpParameters); err != nil {
+		return err
+	}
+	a.Tags, a.Notes, a.Group, a.Order = v.Tags, v.Notes, v.Group, v.Order
+	a.Created, a.Modified = v.Created, v.Modified
+	return nil
+}
+
+// Vault of accounts persisted in working directory
+type Vault struct {
+	Version  int        `json:"version"`
+	Accounts []*Account `json:"accounts"`
+}
+
+// NewVault wraps accounts of payload without metadata
+func NewVault(p *Payload) *Vault {
+	v := &Vault{Version: VaultVersion}
+	for _, op := range p.OtpParameters {
+		v.Accounts = append(v.Accounts, &Account{Payload_OtpParameters: op})
+	}
+	return v
+}
+
+// OpenVault reads vault file, missing file yields empty vault
+func OpenVault(filename string) (*Vault, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Vault{Version: VaultVersion}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var v Vault
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if v.Version > VaultVersion {
+		return nil, fmt.Errorf("%s: version %d: %w", filename, v.Version, ErrUnsupported)
+	}
+	v.Version = VaultVersion
+	return &v, nil
+}
+
+// Save writes vault atomically by renaming a synced temporary file
+func (v *Vault) Save(filename string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// Payload of all accounts in vault, metadata is not included
+func (v *Vault) Payload() *Payload {
+	ops := make([]*Payload_OtpParameters, len(v.Accounts))
+	for i, a := range v.Accounts {
+		ops[i] = a.Payload_OtpParameters
+	}
+	return NewPayload(ops)
+}
+
+// Add account, accounts are identified by their secret
+func (v *Vault) Add(op *Payload_OtpParameters) (*Account, error) {
+	for _, a := range v.Accounts {
+		if a.UUID() == op.UUID() {
+			return nil, fmt.Errorf("%s: %w", a.Name, ErrExists)
+		}
+	}
+	now := time.Now().UTC()
+	a := &Account{Payload_OtpParameters: op, Created: now, Modified: now}
+	v.Accounts = append(v.Accounts, a)
+	return a, nil
+}
+
+// Find single account matching query, see Payload.Find
+func (v *Vault) Find(query string) (*Account, error) {
+	op, err := v.Payload().Find(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range v.Accounts {
+		if a.Payload_OtpParameters == op {
+			return a, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Remove account
+func (v *Vault) Remove(a *Account) {
+	for i, b := range v.Accounts {
+		if a == b {
+			v.Accounts = append(v.Accounts[:i], v.Accounts[i+1:]...)
+			return
+		}
+	}
+}
+
+// Touch marks account as modified
+func (a *Account) Touch() {
+	a.Modified = time.Now().UTC()
+}
+
+// Rename sets issuer and account part of name
+func (a *Account) Rename(issuer, account string) {
+	a.Issuer, a.Name = issuer, label(issuer, account)
+	a.Touch()
+}
+
+// HasTag reports whether account is tagged with tag
+func (a *Account) HasTag(tag string) bool {
+	return slices.Contains(a.Tags, tag)
+}
+
+// Filter returns accounts having tag and group, empty values match any
+func (v *Vault) Filter(tag, group string) *Vault {
+	w := &Vault{Version: v.Version}
+	for _, a := range v.Accounts {
+		if (tag == "" || a.HasTag(tag)) && (group == "" || a.Group == group) {
+			w.Accounts = append(w.Accounts, a)
+		}
+	}
+	return w
+}
+
+// Sort accounts by manual order, accounts without order follow in insertion order
+func (v *Vault) Sort() {
+	slices.SortStableFunc(v.Accounts, func(a, b *Account) int {
+		switch {
+		case a.Order == b.Order:
+			return 0
+		case a.Order == 0:
+			return 1
+		case b.Order == 0:
+			return -1
+		}
+		return cmp.Compare(a.Order, b.Order)
+	})
+}
+
+// Group of accounts sharing group name
+type Group struct {
+	Name     string
+	Accounts []*Account
+}
+
+// Groups of accounts in order of first appearance, ungrouped accounts last
+func (v *Vault) Groups() []Group {
+	var groups []Group
+	var rest []*Account
+	index := make(map[string]int)
+	for _, a := range v.Accounts {
+		if a.Group == "" {
+			rest = append(rest, a)
+			continue
+		}
+		i, ok := index[a.Group]
+		if !ok {
+			i = len(groups)
+			index[a.Group] = i
+			groups = append(groups, Group{Name: a.Group})
+		}
+		groups[i].Accounts = append(groups[i].Accounts, a)
+	}
+	if len(rest) > 0 {
+		groups = append(groups, Group{Accounts: rest})
+	}
+	return groups
+}
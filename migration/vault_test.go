@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "vault.json")
+	v, err := OpenVault(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := []*Payload_OtpParameters{
+		{Secret: []byte{1, 2, 3}, Name: "GitHub:alice", Issuer: "GitHub", Type: Payload_OtpParameters_OTP_TYPE_TOTP},
+		{Secret: []byte{4, 5, 6}, Name: "bob", Type: Payload_OtpParameters_OTP_TYPE_HOTP, Counter: 7},
+	}
+	for _, op := range ops {
+		if _, err := v.Add(op); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v.Accounts[0].Tags = []string{"prod"}
+	v.Accounts[0].Notes = "shared"
+	v.Accounts[0].Group = "work"
+	v.Accounts[0].Order = 2
+	if _, err := v.Add(&Payload_OtpParameters{Secret: []byte{1, 2, 3}}); !errors.Is(err, ErrExists) {
+		t.Errorf("got error %v; want %v", err, ErrExists)
+	}
+	if err := v.Save(fname); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(fname); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, error %v; want 0600", fi.Mode().Perm(), err)
+	}
+	w, err := OpenVault(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Accounts) != len(ops) {
+		t.Fatalf("got %d accounts; want %d", len(w.Accounts), len(ops))
+	}
+	for i, a := range w.Accounts {
+		if a.URL().String() != ops[i].URL().String() || a.Created.IsZero() {
+			t.Errorf("got %v created %v; want %v", a.URL(), a.Created, ops[i].URL())
+		}
+	}
+	if a := w.Accounts[0]; !a.HasTag("prod") || a.Notes != "shared" || a.Group != "work" || a.Order != 2 {
+		t.Errorf("got tags %v notes %v group %v order %v", a.Tags, a.Notes, a.Group, a.Order)
+	}
+	a, err := w.Find("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Rename("Example", "bob@example.com")
+	if a.Name != "Example:bob@example.com" || a.Issuer != "Example" {
+		t.Errorf("got name %v issuer %v after rename", a.Name, a.Issuer)
+	}
+	w.Remove(a)
+	if len(w.Accounts) != 1 || w.Accounts[0].Name != "GitHub:alice" {
+		t.Errorf("got %v after remove", w.Accounts)
+	}
+}
+
+func TestVaultVersion(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "vault.json")
+	if err := os.WriteFile(fname, []byte(`{"version": 99, "accounts": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenVault(fname); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("got error %v; want %v", err, ErrUnsupported)
+	}
+}
+
+func TestVaultGroups(t *testing.T) {
+	v := &Vault{Accounts: []*Account{
+		{Payload_OtpParameters: &Payload_OtpParameters{Name: "a"}},
+		{Payload_OtpParameters: &Payload_OtpParameters{Name: "b"}, Group: "work", Order: 2, Tags: []string{"prod"}},
+		{Payload_OtpParameters: &Payload_OtpParameters{Name: "c"}, Group: "home"},
+		{Payload_OtpParameters: &Payload_OtpParameters{Name: "d"}, Group: "work", Order: 1},
+	}}
+	v.Sort()
+	var names []string
+	for _, g := range v.Groups() {
+		names = append(names, g.Name+":")
+		for _, a := range g.Accounts {
+			names = append(names, a.Name)
+		}
+	}
+	if got, want := strings.Join(names, " "), "work: d b home: c : a"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if got := v.Filter("prod", "").Accounts; len(got) != 1 || got[0].Name != "b" {
+		t.Errorf("got %v; want b", got)
+	}
+	if got := v.Filter("", "work").Accounts; len(got) != 2 {
+		t.Errorf("got %v; want 2 accounts", got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"os"
+	"time"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// iterations of PBKDF2-SHA256 deriving key from passphrase
+const offlineIterations = 600000
+
+type offlineAccount struct {
+	UUID       string   `json:"uuid"`
+	Name       string   `json:"name"`
+	Issuer     string   `json:"issuer"`
+	Secret     string   `json:"secret"`
+	Tuples     []string `json:"tuples"`
+	Algorithm  string   `json:"algorithm"`
+	Digits     int      `json:"digits"`
+	Period     int      `json:"period"`
+	Counter    uint64   `json:"counter"`
+	Parameters string   `json:"parameters"`
+	URL        string   `json:"url"`
+	QR         string   `json:"qr"` // data URI of PNG
+}
+
+type offlineGroup struct {
+	Name     string           `json:"name"`
+	Accounts []offlineAccount `json:"accounts"`
+}
+
+// sealed groups encrypted with AES-GCM, key derived by PBKDF2-SHA256
+type sealed struct {
+	Salt []byte `json:"salt"`
+	IV   []byte `json:"iv"`
+	Iter int    `json:"iter"`
+	Data []byte `json:"data"`
+}
+
+type offlineBackup struct {
+	Groups []offlineGroup `json:"groups,omitempty"`
+	Sealed *sealed        `json:"sealed,omitempty"`
+}
+
+func newOfflineAccount(op *migration.Payload_OtpParameters) (offlineAccount, error) {
+	pic, err := migration.QR(op.URL())
+	if err != nil {
+		return offlineAccount{}, err
+	}
+	return offlineAccount{
+		UUID:       op.UUID().String(),
+		Name:       op.Name,
+		Issuer:     op.Issuer,
+		Secret:     op.SecretString(),
+		Tuples:     op.SecretTuples(),
+		Algorithm:  op.Algorithm.Name(),
+		Digits:     op.Digits.Count(),
+		Period:     op.Period(),
+		Counter:    op.Counter,
+		Parameters: op.Parameters(),
+		URL:        op.URL().String(),
+		QR:         "data:image/png;base64," + base64.StdEncoding.EncodeToString(pic),
+	}, nil
+}
+
+// seal encrypts data for in-browser decryption via WebCrypto
+func seal(data []byte, passphrase string) (*sealed, error) {
+	s := &sealed{Salt: make([]byte, 16), IV: make([]byte, 12), Iter: offlineIterations}
+	rand.Read(s.Salt)
+	rand.Read(s.IV)
+	key, err := pbkdf2.Key(sha256.New, passphrase, s.Salt, s.Iter, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.Data = gcm.Seal(nil, s.IV, data, nil)
+	return s, nil
+}
+
+// offlineHTML renders single self-contained HTML file with all accounts and
+// code generator, encrypted if passphrase is not empty
+func offlineHTML(v *migration.Vault, date time.Time, passphrase string) ([]byte, error) {
+	var groups []offlineGroup
+	for _, g := range v.Groups() {
+		og := offlineGroup{Name: g.Name}
+		for _, a := range g.Accounts {
+			oa, err := newOfflineAccount(a.Payload_OtpParameters)
+			if err != nil {
+				return nil, err
+			}
+			og.Accounts = append(og.Accounts, oa)
+		}
+		groups = append(groups, og)
+	}
+	backup := offlineBackup{Groups: groups}
+	if passphrase != "" {
+		data, err := json.Marshal(groups)
+		if err != nil {
+			return nil, err
+		}
+		s, err := seal(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		backup = offlineBackup{Sealed: s}
+	}
+	style, err := static.ReadFile("static/style.css")
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.ParseFS(static, "static/offline.html")
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	err = t.Execute(&b, struct {
+		Date   string
+		Style  template.CSS
+		Backup offlineBackup
+	}{
+		Date:   date.Format(time.DateOnly),
+		Style:  template.CSS(style),
+		Backup: backup,
+	})
+	return b.Bytes(), err
+}
+
+func writeOffline(v *migration.Vault, out string, encrypt bool) error {
+	var passphrase string
+	if encrypt {
+		var err error
+		if passphrase, err = readPassphrase(); err != nil {
+			return err
+		}
+	}
+	data, err := offlineHTML(v, time.Now(), passphrase)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, data, 0600)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// unseal decrypts sealed data as static/offline.html does with WebCrypto:
+// PBKDF2 with SHA-256 deriving AES-GCM key of 256 bits, default 128 bit tag
+func unseal(t *testing.T, s *sealed, passphrase string) ([]byte, error) {
+	t.Helper()
+	// fields as seen by browser after JSON round trip
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Salt []byte `json:"salt"`
+		IV   []byte `json:"iv"`
+		Iter int    `json:"iter"`
+		Data []byte `json:"data"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, got.Salt, got.Iter, 256/8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(got.IV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gcm.Open(nil, got.IV, got.Data, nil)
+}
+
+func TestSeal(t *testing.T) {
+	want := []byte(`[{"name":"work"}]`)
+	s, err := seal(want, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Iter != offlineIterations || len(s.Salt) != 16 || len(s.IV) != 12 {
+		t.Errorf("got iter %d salt %d iv %d", s.Iter, len(s.Salt), len(s.IV))
+	}
+	got, err := unseal(t, s, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if _, err := unseal(t, s, "wrong horse"); err == nil {
+		t.Error("wrong passphrase: got no error")
+	}
+}
+
+func TestOfflineHTML(t *testing.T) {
+	op := &migration.Payload_OtpParameters{
+		Name:   "Example:alice",
+		Issuer: "Example",
+		Secret: []byte("Hello!\xde\xad\xbe\xef"),
+		Type:   migration.Payload_OtpParameters_OTP_TYPE_TOTP,
+	}
+	v := migration.NewVault(migration.NewPayload([]*migration.Payload_OtpParameters{op}))
+	date := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	secret := []byte(op.SecretString())
+	plain, err := offlineHTML(v, date, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(plain, secret) {
+		t.Errorf("plain HTML lacks secret %s", secret)
+	}
+	encrypted, err := offlineHTML(v, date, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encrypted, secret) {
+		t.Errorf("encrypted HTML contains secret %s", secret)
+	}
+	if bytes.Contains(encrypted, []byte(op.Name)) {
+		t.Errorf("encrypted HTML contains account name %s", op.Name)
+	}
+}
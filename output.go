@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+type account struct {
+	UUID      string `json:"uuid"`
+	Name      string `json:"name"`
+	Issuer    string `json:"issuer"`
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period"`
+	Counter   uint64 `json:"counter"`
+	URL       string `json:"url"`
+}
+
+func newAccount(op *migration.Payload_OtpParameters) account {
+	return account{
+		UUID:      op.UUID().String(),
+		Name:      op.Name,
+		Issuer:    op.Issuer,
+		Type:      op.Type.Name(),
+		Algorithm: op.Algorithm.Name(),
+		Digits:    op.Digits.Count(),
+		Period:    op.Period(),
+		Counter:   op.Counter,
+		URL:       op.URL().String(),
+	}
+}
+
+type evaluation struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Issuer  string `json:"issuer"`
+	Code    string `json:"code"`
+	Seconds int    `json:"seconds"` // remaining
+	Period  int    `json:"period"`
+	Digits  int    `json:"digits"`
+}
+
+func newEvaluation(op *migration.Payload_OtpParameters) evaluation {
+	return evaluation{
+		UUID:    op.UUID().String(),
+		Name:    op.Name,
+		Issuer:  op.Issuer,
+		Code:    op.EvaluateString(),
+		Seconds: op.Remaining(),
+		Period:  op.Period(),
+		Digits:  op.Digits.Count(),
+	}
+}
+
+type batchInfo struct {
+	Version    int32  `json:"version"`
+	BatchSize  int32  `json:"batch_size"`
+	BatchIndex int32  `json:"batch_index"`
+	BatchID    int32  `json:"batch_id"`
+	Accounts   int    `json:"accounts"`
+	Checksum   string `json:"checksum"`
+}
+
+func newBatchInfo(p *migration.Payload, checksum string) batchInfo {
+	return batchInfo{
+		Version:    p.Version,
+		BatchSize:  p.BatchSize,
+		BatchIndex: p.BatchIndex,
+		BatchID:    p.BatchId,
+		Accounts:   len(p.OtpParameters),
+		Checksum:   checksum,
+	}
+}
+
+// records maps every account of payload
+func records[T any](p *migration.Payload, f func(*migration.Payload_OtpParameters) T) []T {
+	r := make([]T, len(p.OtpParameters))
+	for i, op := range p.OtpParameters {
+		r[i] = f(op)
+	}
+	return r
+}
+
+// header returns json field names of a flat record type
+func header[T any]() []string {
+	t := reflect.TypeFor[T]()
+	header := make([]string, t.NumField())
+	for i := range header {
+		header[i] = t.Field(i).Tag.Get("json")
+	}
+	return header
+}
+
+// row returns field values of a flat record
+func row(v any) []string {
+	rv := reflect.ValueOf(v)
+	row := make([]string, rv.NumField())
+	for i := range row {
+		row[i] = fmt.Sprint(rv.Field(i).Interface())
+	}
+	return row
+}
+
+// writeRecords in one of json, jsonl, csv or table formats
+func writeRecords[T any](w io.Writer, format string, records []T) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header[T]()); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(row(r)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header[T](), "\t")))
+		for _, r := range records {
+			fmt.Fprintln(tw, strings.Join(row(r), "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("output %s: %w", format, migration.ErrUnknown)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+func TestWriteRecords(t *testing.T) {
+	type record struct {
+		Name    string `json:"name"`
+		Issuer  string `json:"issuer"`
+		Counter uint64 `json:"counter"`
+	}
+	records := []record{
+		{Name: "Example:alice", Issuer: "Example", Counter: 1},
+		{Name: "bob, jr.", Counter: 0},
+	}
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "csv",
+			want:   "name,issuer,counter\nExample:alice,Example,1\n\"bob, jr.\",,0\n",
+		},
+		{
+			format: "table",
+			want:   "NAME           ISSUER   COUNTER\nExample:alice  Example  1\nbob, jr.                0\n",
+		},
+		{
+			format: "jsonl",
+			want:   "{\"name\":\"Example:alice\",\"issuer\":\"Example\",\"counter\":1}\n{\"name\":\"bob, jr.\",\"issuer\":\"\",\"counter\":0}\n",
+		},
+		{
+			format: "json",
+			want:   "[\n  {\n    \"name\": \"Example:alice\",\n    \"issuer\": \"Example\",\n    \"counter\": 1\n  },\n  {\n    \"name\": \"bob, jr.\",\n    \"issuer\": \"\",\n    \"counter\": 0\n  }\n]\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeRecords(&b, tc.format, records); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+	if err := writeRecords(&strings.Builder{}, "xml", records); !errors.Is(err, migration.ErrUnknown) {
+		t.Errorf("xml: got error %v; want %v", err, migration.ErrUnknown)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	got := strings.Join(header[account](), ",")
+	const want = "uuid,name,issuer,type,algorithm,digits,period,counter,url"
+	if got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
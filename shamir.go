@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+// writeShares splits payload as given by k-of-n into share files and QR-codes,
+// all QR-codes are encoded before any file is written
+func writeShares(p *migration.Payload, workdir, kn string) error {
+	var k, n int
+	if _, err := fmt.Sscanf(kn, "%d-of-%d", &k, &n); err != nil {
+		return fmt.Errorf("shamir %s: want k-of-n: %w", kn, err)
+	}
+	shares, err := p.Split(k, n)
+	if err != nil {
+		return err
+	}
+	pics := make([][]byte, len(shares))
+	for i, s := range shares {
+		if pics[i], err = migration.QR(s.URL()); err != nil {
+			return fmt.Errorf("share QR-code of %d accounts: %w", len(p.OtpParameters), err)
+		}
+	}
+	for i, s := range shares {
+		name := filepath.Join(workdir, fmt.Sprintf("share-%d-of-%d", s.X, s.N))
+		if err := os.WriteFile(name+".txt", []byte(s.URL().String()+"\n"), 0600); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name+".png", pics[i], 0600); err != nil {
+			return fmt.Errorf("write file: %w", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d shares, any %d recover accounts\n", n, k)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/dim13/otpauth/migration"
+	"google.golang.org/protobuf/proto"
+)
+
+// templateAccount exposes copy of otp parameters and their current code to
+// -format, all methods used by static/index.html (URL, SecretString,
+// SecretTuples, UUID, EvaluateString, Seconds) are available as well
+type templateAccount struct {
+	*migration.Payload_OtpParameters
+}
+
+// Code evaluates on own copy, so HOTP counter shown by template is unchanged
+func (a templateAccount) Code() string {
+	return proto.CloneOf(a.Payload_OtpParameters).EvaluateString()
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+var unescape = strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\\`, `\`)
+
+// renderTemplate executes text/template once per account
+func renderTemplate(w io.Writer, text string, p *migration.Payload) error {
+	text = unescape.Replace(text)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	t, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+	for _, op := range p.OtpParameters {
+		a := templateAccount{Payload_OtpParameters: proto.CloneOf(op)}
+		if err := t.Execute(w, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
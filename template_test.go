@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dim13/otpauth/migration"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	hotp := &migration.Payload_OtpParameters{
+		Name:    "Example:alice",
+		Issuer:  "Example",
+		Secret:  []byte("12345678901234567890"),
+		Type:    migration.Payload_OtpParameters_OTP_TYPE_HOTP,
+		Counter: 0,
+	}
+	p := migration.NewPayload([]*migration.Payload_OtpParameters{hotp})
+	var b strings.Builder
+	// RFC 4226 test vector for counter 1, code is evaluated twice
+	if err := renderTemplate(&b, `{{.Issuer | upper}}\t{{.Code}}\t{{.Code}}\t{{.Counter}}`, p); err != nil {
+		t.Fatal(err)
+	}
+	const want = "EXAMPLE\t287082\t287082\t0\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if hotp.Counter != 0 {
+		t.Errorf("got counter %d; want 0", hotp.Counter)
+	}
+	if err := renderTemplate(&b, "{{.Bogus}}", p); err == nil {
+		t.Error("unknown field: got no error")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dim13/otpauth/migration"
+	"golang.org/x/term"
+)
+
+var termModes = map[string]migration.TermMode{
+	"half": migration.HalfBlock,
+	"ansi": migration.ANSI,
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// pager writes pages one at a time, waiting for Enter between them
+// when attached to a terminal
+func pager(w io.Writer, pages []string) error {
+	interactive := isTerminal(os.Stdin) && isTerminal(os.Stdout)
+	r := bufio.NewReader(os.Stdin)
+	for i, page := range pages {
+		if _, err := io.WriteString(w, page); err != nil {
+			return err
+		}
+		if !interactive || i == len(pages)-1 {
+			continue
+		}
+		fmt.Fprintf(w, "[%d/%d] Enter for next, q to quit: ", i+1, len(pages))
+		line, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			// Ctrl-D quits as well
+			fmt.Fprintln(w)
+			return nil
+		}
+		if err != nil || strings.TrimSpace(line) == "q" {
+			return err
+		}
+	}
+	return nil
+}
+
+type termQR struct {
+	title string
+	url   *url.URL
+}
+
+// showQR renders QR codes in terminal and pages through them
+func showQR(mode string, invert bool, codes []termQR) error {
+	m, ok := termModes[mode]
+	if !ok {
+		return fmt.Errorf("terminal mode %s: %w", mode, migration.ErrUnknown)
+	}
+	pages := make([]string, len(codes))
+	for i, c := range codes {
+		s, err := migration.Terminal(c.url, m, invert)
+		if err != nil {
+			return err
+		}
+		pages[i] = c.title + "\n" + s
+	}
+	return pager(os.Stdout, pages)
+}
+
+// readPassphrase prompts twice on terminal, otherwise reads first line of stdin
+func readPassphrase() (string, error) {
+	a, err := readHidden("Passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		b, err := readHidden("Repeat passphrase: ")
+		if err != nil {
+			return "", fmt.Errorf("read passphrase: %w", err)
+		}
+		if a != b {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	if a == "" {
+		return "", errors.New("empty passphrase")
+	}
+	return a, nil
+}
+
+// readHidden reads line from terminal without echo after prompt,
+// or first line of stdin if it is not a terminal
+func readHidden(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(b), err
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dim13/otpauth/migration"
+	"github.com/google/uuid"
+)
+
+const vaultFilename = "vault.json"
+
+func (in *input) vaultFile() string {
+	return filepath.Join(in.workdir, vaultFilename)
+}
+
+// vaultRecord is account listing with vault metadata
+type vaultRecord struct {
+	UUID      string `json:"uuid"`
+	Name      string `json:"name"`
+	Issuer    string `json:"issuer"`
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Counter   uint64 `json:"counter"`
+	Group     string `json:"group"`
+	Tags      string `json:"tags"`
+	Order     int    `json:"order"`
+	Notes     string `json:"notes"`
+	Created   string `json:"created"`
+	Modified  string `json:"modified"`
+}
+
+func newVaultRecord(a *migration.Account) vaultRecord {
+	return vaultRecord{
+		UUID:      a.UUID().String(),
+		Name:      a.Name,
+		Issuer:    a.Issuer,
+		Type:      a.Type.Name(),
+		Algorithm: a.Algorithm.Name(),
+		Digits:    a.Digits.Count(),
+		Counter:   a.Counter,
+		Group:     a.Group,
+		Tags:      strings.Join(a.Tags, ","),
+		Order:     a.Order,
+		Notes:     a.Notes,
+		Created:   a.Created.Format(time.RFC3339),
+		Modified:  a.Modified.Format(time.RFC3339),
+	}
+}
+
+// manualURL builds otpauth link from fields given on command line
+func manualURL(typ, issuer, name, secret, algorithm string, digits int, counter uint64) string {
+	v := make(url.Values)
+	v.Add("secret", secret)
+	if issuer != "" {
+		v.Add("issuer", issuer)
+	}
+	if algorithm != "" {
+		v.Add("algorithm", algorithm)
+	}
+	if digits != 0 {
+		v.Add("digits", fmt.Sprint(digits))
+	}
+	if typ == "hotp" {
+		v.Add("counter", fmt.Sprint(counter))
+	}
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     typ,
+		Path:     issuer + ":" + name,
+		RawQuery: v.Encode(),
+	}
+	if issuer == "" {
+		u.Path = name
+	}
+	return u.String()
+}
+
+// splitTags of comma separated list, dropping empty ones
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// interspersed parses flags mixed with positional arguments and returns the latter
+func interspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := parseArgs(fs, args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos, args = append(pos, fs.Arg(0)), fs.Args()[1:]
+	}
+}
+
+// vaultCmd manages accounts persisted in working directory
+func vaultCmd(fs *flag.FlagSet, in *input, args []string) error {
+	var (
+		name      = fs.String("name", "", "account name without issuer")
+		issuer    = fs.String("issuer", "", "issuer")
+		typ       = fs.String("type", "", "otp type (totp, hotp)")
+		algorithm = fs.String("algorithm", "", "hash algorithm (SHA1, SHA256, SHA512, MD5)")
+		digits    = fs.Int("digits", 0, "number of digits (6, 8)")
+		counter   = fs.Uint64("counter", 0, "hotp counter")
+		tags      = fs.String("tags", "", "comma separated tags, replaces existing")
+		notes     = fs.String("notes", "", "free-text notes")
+		order     = fs.Int("order", 0, "manual sort order, 0 for none")
+		output    = fs.String("o", "table", outputUsage)
+	)
+	// flags may follow subcommand and query, config fills the rest
+	args, err := interspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	set, err := parseExplicit(fs, nil)
+	if err != nil {
+		return err
+	}
+	var sub string
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	if err := in.mkdir(); err != nil {
+		return err
+	}
+	v, err := migration.OpenVault(in.vaultFile())
+	if err != nil {
+		return err
+	}
+	// account selected by query for remove, rename and edit
+	find := func() (*migration.Account, error) {
+		if len(args) != 1 {
+			fs.Usage()
+			return nil, exitCode(exitUsage)
+		}
+		a, err := v.Find(args[0])
+		if err != nil {
+			return nil, matchError(err)
+		}
+		return a, nil
+	}
+	switch sub {
+	case "add":
+		var ops []*migration.Payload_OtpParameters
+		switch {
+		case len(args) > 0:
+			for _, arg := range args {
+				p, err := readPayload(arg, in.from)
+				if err != nil {
+					return err
+				}
+				ops = append(ops, p.OtpParameters...)
+			}
+		case set["name"]:
+			// secret is read from terminal or stdin, never from command line
+			secret, err := readHidden("Secret: ")
+			if err != nil {
+				return fmt.Errorf("read secret: %w", err)
+			}
+			if secret = strings.TrimSpace(secret); secret == "" {
+				return errors.New("empty secret")
+			}
+			if *typ == "" {
+				*typ = "totp"
+			}
+			op, err := migration.ParseURL(manualURL(*typ, *issuer, *name, secret, *algorithm, *digits, *counter))
+			if err != nil {
+				return err
+			}
+			ops = append(ops, op)
+		case in.link != "" || in.linkFile != "" || in.in != "":
+			p, err := in.load()
+			if err != nil {
+				return err
+			}
+			ops = p.OtpParameters
+		default:
+			fs.Usage()
+			return exitCode(exitUsage)
+		}
+		var added int
+		for _, op := range ops {
+			if _, err := v.Add(op); err != nil {
+				fmt.Fprintln(os.Stderr, "otpauth: skip", err)
+				continue
+			}
+			added++
+		}
+		fmt.Fprintf(os.Stderr, "added %d accounts\n", added)
+	case "remove":
+		a, err := find()
+		if err != nil {
+			return err
+		}
+		v.Remove(a)
+		fmt.Fprintf(os.Stderr, "removed %s\n", a.Name)
+	case "rename":
+		a, err := find()
+		if err != nil {
+			return err
+		}
+		newIssuer, newName := a.Issuer, a.Account()
+		if set["issuer"] {
+			newIssuer = *issuer
+		}
+		if set["name"] {
+			newName = *name
+		}
+		a.Rename(newIssuer, newName)
+	case "edit":
+		a, err := find()
+		if err != nil {
+			return err
+		}
+		if set["digits"] {
+			if a.Digits, err = migration.ParseDigits(*digits); err != nil {
+				return err
+			}
+		}
+		if set["algorithm"] {
+			if a.Algorithm, err = migration.ParseAlgorithm(*algorithm); err != nil {
+				return err
+			}
+		}
+		switch {
+		case !set["type"]:
+		case *typ == "totp":
+			a.Type = migration.Payload_OtpParameters_OTP_TYPE_TOTP
+		case *typ == "hotp":
+			a.Type = migration.Payload_OtpParameters_OTP_TYPE_HOTP
+		default:
+			return fmt.Errorf("type %s: %w", *typ, migration.ErrUnknown)
+		}
+		if set["counter"] {
+			a.Counter = *counter
+		}
+		// -group assigns group instead of selecting accounts
+		if set["group"] {
+			a.Group = in.group
+		}
+		if set["tags"] {
+			a.Tags = splitTags(*tags)
+		}
+		if set["notes"] {
+			a.Notes = *notes
+		}
+		if set["order"] {
+			a.Order = *order
+		}
+		a.Touch()
+	case "list":
+		v = v.Filter(in.tag, in.group)
+		v.Sort()
+		if *output == "text" {
+			for _, a := range v.Accounts {
+				fmt.Println(a.Name)
+			}
+			return nil
+		}
+		r := make([]vaultRecord, len(v.Accounts))
+		for i, a := range v.Accounts {
+			r[i] = newVaultRecord(a)
+		}
+		return writeRecords(os.Stdout, *output, r)
+	default:
+		fs.Usage()
+		return exitCode(exitUsage)
+	}
+	return v.Save(in.vaultFile())
+}
+
+// explicit input overrides vault and cache
+func (in *input) explicit() bool {
+	return in.link != "" || in.linkFile != "" || in.in != ""
+}
+
+// loadVault returns sorted vault accounts selected by -tag and -group,
+// or nil if input is given explicitly or vault is empty
+func (in *input) loadVault() (*migration.Vault, error) {
+	if in.explicit() {
+		return nil, nil
+	}
+	v, err := migration.OpenVault(in.vaultFile())
+	if err != nil || len(v.Accounts) == 0 {
+		return nil, err
+	}
+	v = v.Filter(in.tag, in.group)
+	v.Sort()
+	return v, nil
+}
+
+// accounts from vault with metadata, or from any other input without
+func (in *input) accounts() (*migration.Vault, error) {
+	v, err := in.loadVault()
+	if err != nil || v != nil {
+		return v, err
+	}
+	p, err := in.load()
+	if err != nil {
+		return nil, err
+	}
+	return migration.NewVault(p), nil
+}
+
+// store adds accounts to vault if it is in use, otherwise replaces cache,
+// so stored accounts are seen by the same store load reads
+func (in *input) store(verb string, p *migration.Payload) error {
+	v, err := migration.OpenVault(in.vaultFile())
+	if err != nil {
+		return err
+	}
+	if len(v.Accounts) == 0 {
+		data, err := migration.Marshal(p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s %d accounts\n", verb, len(p.OtpParameters))
+		return os.WriteFile(in.cacheFile(), data, 0600)
+	}
+	var added int
+	for _, op := range p.OtpParameters {
+		if _, err := v.Add(op); err != nil {
+			fmt.Fprintln(os.Stderr, "otpauth: skip", err)
+			continue
+		}
+		added++
+	}
+	fmt.Fprintf(os.Stderr, "%s %d accounts into %s\n", verb, added, vaultFilename)
+	return v.Save(in.vaultFile())
+}
+
+// saveCounter persists HOTP counters advanced by evaluation of vault accounts
+func (in *input) saveCounter(ops ...*migration.Payload_OtpParameters) error {
+	counters := make(map[uuid.UUID]uint64)
+	for _, op := range ops {
+		if op.Type == migration.Payload_OtpParameters_OTP_TYPE_HOTP {
+			counters[op.UUID()] = op.Counter
+		}
+	}
+	if len(counters) == 0 || in.explicit() {
+		return nil
+	}
+	v, err := migration.OpenVault(in.vaultFile())
+	if err != nil {
+		return err
+	}
+	var changed bool
+	for _, a := range v.Accounts {
+		if c, ok := counters[a.UUID()]; ok && c != a.Counter {
+			a.Counter = c
+			a.Touch()
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return v.Save(in.vaultFile())
+}
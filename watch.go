@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dim13/otpauth/migration"
+	"golang.org/x/term"
+)
+
+const (
+	barWidth  = 30
+	expiresIn = 10 // seconds of validity left to highlight code
+	escWait   = 20 * time.Millisecond
+)
+
+func matches(op *migration.Payload_OtpParameters, filter string) bool {
+	filter = strings.ToLower(filter)
+	return strings.Contains(strings.ToLower(op.Name), filter) ||
+		strings.Contains(strings.ToLower(op.Issuer), filter)
+}
+
+func countdown(remaining, period int) string {
+	n := barWidth * remaining / period
+	return strings.Repeat("█", n) + strings.Repeat("░", barWidth-n)
+}
+
+// drawWatch redraws full screen, lines are terminated by \r\n in raw mode
+func drawWatch(w *bufio.Writer, p *migration.Payload, filter string) error {
+	w.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(w, "otpauth watch, type to filter, Esc to clear, Ctrl-C to quit\r\n")
+	fmt.Fprintf(w, "filter: %s\r\n\r\n", filter)
+	width := 0
+	for _, op := range p.OtpParameters {
+		width = max(width, len(op.Name))
+	}
+	for _, op := range p.OtpParameters {
+		if !matches(op, filter) {
+			continue
+		}
+		if op.Period() == 0 {
+			// do not advance counter on every redraw
+			fmt.Fprintf(w, "%-*s  %s  (counter %d)\r\n", width, op.Name, op.NextString(), op.Counter+1)
+			continue
+		}
+		// real time the shown code stays valid, including look-ahead
+		code, valid := op.EvaluateString(), op.Valid()
+		if valid <= expiresIn {
+			code = "\x1b[1;31m" + code + "\x1b[0m"
+		}
+		fmt.Fprintf(w, "%-*s  %s  next %s  %s %2ds\r\n", width, op.Name, code,
+			op.NextString(), countdown(min(valid, op.Period()), op.Period()), valid)
+	}
+	return w.Flush()
+}
+
+// skipEscape consumes rest of escape sequence following Esc, like CSI
+// "\x1b[A" or SS3 "\x1bOP", and reports whether there was one. Sequences
+// arrive at once, a lone Esc is followed by nothing within escWait.
+func skipEscape(keys <-chan byte) bool {
+	next := func() (byte, bool) {
+		select {
+		case c, ok := <-keys:
+			return c, ok
+		case <-time.After(escWait):
+			return 0, false
+		}
+	}
+	c, ok := next()
+	switch {
+	case !ok:
+		return false
+	case c == '[':
+		// parameters up to final byte
+		for {
+			c, ok := next()
+			if !ok || c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+	case c == 'O':
+		next()
+	}
+	return true
+}
+
+// watch shows live view of all codes until interrupted
+func watch(p *migration.Payload) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("watch: stdin is not a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	// alternate screen, hide cursor
+	w.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		w.WriteString("\x1b[?25h\x1b[?1049l")
+		w.Flush()
+		term.Restore(fd, state)
+	}()
+
+	keys := make(chan byte)
+	go func() {
+		r := bufio.NewReader(os.Stdin)
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- c
+		}
+	}()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	var filter []rune
+	for {
+		if err := drawWatch(w, p, string(filter)); err != nil {
+			return err
+		}
+		select {
+		case <-sig:
+			return nil
+		case <-t.C:
+		case c, ok := <-keys:
+			switch {
+			case !ok, c == 3, c == 4: // EOF, Ctrl-C, Ctrl-D
+				return nil
+			case c == 27: // Esc, unless it starts escape sequence of arrow or function key
+				if !skipEscape(keys) {
+					filter = nil
+				}
+			case c == 127, c == 8: // Backspace
+				if len(filter) > 0 {
+					filter = filter[:len(filter)-1]
+				}
+			case c >= ' ' && c < 127:
+				filter = append(filter, rune(c))
+			}
+		}
+	}
+}